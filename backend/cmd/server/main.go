@@ -62,26 +62,51 @@ func main() {
 	reservationRepo := repository.NewReservationRepository(db)
 	billRepo := repository.NewBillRepository(db)
 	paymentRepo := repository.NewPaymentRepository(db)
+	creditRepo := repository.NewCustomerCreditRepository(db)
+	paymentMethodRepo := repository.NewPaymentMethodRepository(db)
 	settingsRepo := repository.NewSettingsRepository(db)
+	taxSlabRepo := repository.NewTaxSlabRepository(db)
+	folioRepo := repository.NewFolioRepository(db)
+	catalogRepo := repository.NewCatalogRepository(db)
+	kitchenOrderRepo := repository.NewKitchenOrderRepository(db)
+	ratePlanRepo := repository.NewRatePlanRepository(db)
 
 	// Initialize services
 	authService := services.NewAuthService(userRepo, cfg.JWTSecret)
-	customerService := services.NewCustomerService(customerRepo)
+	customerService := services.NewCustomerService(customerRepo, creditRepo)
 	roomService := services.NewRoomService(roomRepo)
-	reservationService := services.NewReservationService(reservationRepo, roomRepo)
-	billService := services.NewBillService(billRepo, settingsRepo)
-	paymentService := services.NewPaymentService(paymentRepo, billRepo)
+	reservationService := services.NewReservationService(reservationRepo, roomRepo, billRepo, paymentMethodRepo, ratePlanRepo, creditRepo)
+	billService := services.NewBillService(billRepo, settingsRepo, customerRepo, taxSlabRepo, reservationRepo, paymentRepo, creditRepo, folioRepo, catalogRepo, ratePlanRepo)
+	paymentService := services.NewPaymentService(paymentRepo, billRepo, creditRepo, paymentMethodRepo)
 	settingsService := services.NewSettingsService(settingsRepo)
+	taxSlabService := services.NewTaxSlabService(taxSlabRepo)
+	reportService := services.NewReportService(billRepo, paymentRepo, reservationRepo)
+	paymentMethodService := services.NewPaymentMethodService(paymentMethodRepo)
+	catalogService := services.NewCatalogService(catalogRepo)
+	folioService := services.NewFolioService(folioRepo, reservationRepo, catalogRepo)
+	kitchenOrderService := services.NewKitchenOrderService(kitchenOrderRepo, reservationRepo, customerRepo, catalogRepo, settingsRepo, folioService, billService)
+	pricingService := services.NewPricingService(ratePlanRepo, roomRepo)
+	availabilityService := services.NewAvailabilityService(roomRepo, reservationRepo, ratePlanRepo)
+	receiptService := services.NewReceiptService(billService, paymentService, settingsRepo)
 
 	// Initialize handlers
 	h := &routes.Handlers{
-		Auth:        handlers.NewAuthHandler(authService, cfg),
-		Customer:    handlers.NewCustomerHandler(customerService),
-		Room:        handlers.NewRoomHandler(roomService),
-		Reservation: handlers.NewReservationHandler(reservationService),
-		Bill:        handlers.NewBillHandler(billService),
-		Payment:     handlers.NewPaymentHandler(paymentService),
-		Settings:    handlers.NewSettingsHandler(settingsService),
+		Auth:          handlers.NewAuthHandler(authService, cfg),
+		Customer:      handlers.NewCustomerHandler(customerService),
+		Room:          handlers.NewRoomHandler(roomService),
+		Reservation:   handlers.NewReservationHandler(reservationService),
+		Bill:          handlers.NewBillHandler(billService),
+		Payment:       handlers.NewPaymentHandler(paymentService),
+		Settings:      handlers.NewSettingsHandler(settingsService),
+		TaxSlab:       handlers.NewTaxSlabHandler(taxSlabService),
+		Report:        handlers.NewReportHandler(reportService),
+		Receipt:       handlers.NewReceiptHandler(receiptService),
+		PaymentMethod: handlers.NewPaymentMethodHandler(paymentMethodService),
+		Folio:         handlers.NewFolioHandler(folioService, billService),
+		Catalog:       handlers.NewCatalogHandler(catalogService),
+		KitchenOrder:  handlers.NewKitchenOrderHandler(kitchenOrderService),
+		Pricing:       handlers.NewPricingHandler(pricingService),
+		Availability:  handlers.NewAvailabilityHandler(availabilityService),
 	}
 
 	// Setup Gin router
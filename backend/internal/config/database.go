@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"strings"
 	"trinity-lodge/internal/models"
 
 	"github.com/glebarez/sqlite"
@@ -10,7 +11,7 @@ import (
 )
 
 func InitDatabase(dbPath string) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+	db, err := gorm.Open(sqlite.Open(sqliteDSN(dbPath)), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
@@ -23,16 +24,102 @@ func InitDatabase(dbPath string) *gorm.DB {
 		&models.Customer{},
 		&models.RoomType{},
 		&models.Room{},
+		&models.RatePlan{},
+		&models.RatePlanPrice{},
 		&models.Reservation{},
+		&models.ReservationDeposit{},
+		&models.CatalogItem{},
+		&models.Folio{},
+		&models.FolioCharge{},
+		&models.KitchenOrder{},
+		&models.KitchenOrderItem{},
 		&models.Bill{},
 		&models.BillLineItem{},
 		&models.Payment{},
+		&models.CustomerPayment{},
+		&models.PaymentRefund{},
+		&models.PaymentMethodConfig{},
+		&models.CustomerCredit{},
 		&models.Settings{},
+		&models.TaxSlab{},
+		&models.InvoiceCounter{},
+		&models.SchemaMigration{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	if err := runMigrations(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	if err := seedTaxSlabs(db); err != nil {
+		log.Fatalf("Failed to seed tax slabs: %v", err)
+	}
+
+	if err := seedPaymentMethods(db); err != nil {
+		log.Fatalf("Failed to seed payment methods: %v", err)
+	}
+
 	log.Println("Database connected and migrated successfully")
 	return db
 }
+
+// sqliteDSN adds the connection options needed when several terminals write
+// at once: writers wait for the database lock instead of failing straight
+// away, and transactions take the write lock when they begin so two of them
+// can't both read a counter and then deadlock upgrading to write.
+func sqliteDSN(dbPath string) string {
+	sep := "?"
+	if strings.Contains(dbPath, "?") {
+		sep = "&"
+	}
+	return dbPath + sep + "_pragma=busy_timeout(10000)&_txlock=immediate"
+}
+
+// seedTaxSlabs installs the GST slabs for room tariffs on a fresh database:
+// exempt below Rs.1000 per night, 12% up to Rs.7500 and 18% above that.
+func seedTaxSlabs(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&models.TaxSlab{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	exemptMax, midMax := models.Rupees(999.99), models.Rupees(7500)
+	slabs := []models.TaxSlab{
+		{ItemType: models.LineItemTypeRoom, MinAmount: 0, MaxAmount: &exemptMax, Rate: 0, EffectiveFrom: "2017-07-01"},
+		{ItemType: models.LineItemTypeRoom, MinAmount: models.Rupees(1000), MaxAmount: &midMax, Rate: 12, EffectiveFrom: "2017-07-01"},
+		{ItemType: models.LineItemTypeRoom, MinAmount: models.Rupees(7500.01), Rate: 18, EffectiveFrom: "2017-07-01"},
+	}
+	return db.Create(&slabs).Error
+}
+
+// seedPaymentMethods installs the payment methods the front desk starts with.
+// Cheques and bank transfers can't be traced without their reference, so
+// those require one.
+func seedPaymentMethods(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&models.PaymentMethodConfig{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	methods := []models.PaymentMethodConfig{
+		{Name: models.PaymentMethodCash, SortOrder: 1},
+		{Name: models.PaymentMethodCard, SortOrder: 2},
+		{Name: models.PaymentMethodUPI, SortOrder: 3},
+		{Name: models.PaymentMethodBankTransfer, RequiresReference: true, SortOrder: 4},
+		{Name: models.PaymentMethodCheque, RequiresReference: true, SortOrder: 5},
+		{Name: models.PaymentMethodCorporateCredit, SortOrder: 6},
+		{Name: models.PaymentMethodOTAPrepaid, SortOrder: 7},
+	}
+	for i := range methods {
+		methods[i].IsActive = true
+	}
+	return db.Create(&methods).Error
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"trinity-lodge/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migration is a one-off change to existing data that AutoMigrate can't
+// express. Migrations run in order, each in its own transaction, and are
+// recorded in schema_migrations once applied.
+type migration struct {
+	version string
+	up      func(tx *gorm.DB) error
+}
+
+var migrations = []migration{
+	{version: "0001_money_in_paise", up: moneyInPaise},
+	{version: "0002_bill_settlement", up: billSettlement},
+}
+
+func runMigrations(db *gorm.DB) error {
+	for _, m := range migrations {
+		var count int64
+		if err := db.Model(&models.SchemaMigration{}).Where("version = ?", m.version).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&models.SchemaMigration{Version: m.version, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.version, err)
+		}
+		log.Printf("Applied migration %s", m.version)
+	}
+	return nil
+}
+
+// moneyColumns lists every column holding an amount of money
+var moneyColumns = map[string][]string{
+	"bills":           {"subtotal", "tax_amount", "cgst_amount", "sgst_amount", "igst_amount", "discount_amount", "total_amount"},
+	"bill_line_items": {"unit_price", "discount_amount", "amount", "tax_amount"},
+	"payments":        {"amount"},
+	"room_types":      {"default_rate"},
+	"tax_slabs":       {"min_amount", "max_amount"},
+}
+
+// moneyInPaise converts amounts stored as rupee floats into whole paise,
+// rounding halves away from zero as models.Money does
+func moneyInPaise(tx *gorm.DB) error {
+	for table, columns := range moneyColumns {
+		assignments := make([]string, len(columns))
+		for i, column := range columns {
+			assignments[i] = fmt.Sprintf("%[1]s = CAST(ROUND(%[1]s * 100) AS INTEGER)", column)
+		}
+		if err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s", table, strings.Join(assignments, ", "))).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// billSettlement fills in the amount paid and balance due of existing bills
+// and gives issued bills the UNPAID, PARTIALLY_PAID or PAID status they
+// would have had, following models.Bill.ApplyPayments
+func billSettlement(tx *gorm.DB) error {
+	statements := []string{
+		`UPDATE bills SET amount_paid = COALESCE((SELECT SUM(amount) FROM payments WHERE payments.bill_id = bills.id), 0)`,
+		`UPDATE bills SET balance_due = CASE
+			WHEN status = 'CANCELLED' OR document_type = 'CREDIT_NOTE' THEN 0
+			ELSE total_amount - amount_paid - COALESCE((
+				SELECT SUM(notes.total_amount) FROM bills AS notes
+				WHERE notes.original_bill_id = bills.id
+				AND notes.document_type = 'CREDIT_NOTE' AND notes.status <> 'CANCELLED'), 0)
+			END`,
+		`UPDATE bills SET status = CASE
+			WHEN balance_due <= 0 THEN 'PAID'
+			WHEN amount_paid > 0 THEN 'PARTIALLY_PAID'
+			ELSE 'UNPAID'
+			END
+		WHERE status NOT IN ('DRAFT', 'CANCELLED') AND document_type <> 'CREDIT_NOTE'`,
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+// Package escpos builds ESC/POS command streams for thermal receipt printers
+// and sends them to printers on the network.
+package escpos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Paper widths supported by the receipt layouts, in millimetres
+const (
+	Paper58mm = 58
+	Paper80mm = 80
+)
+
+// Columns returns the number of characters that fit on a line of the given
+// paper width in the printer's standard font (font A, 12x24 dots)
+func Columns(paperWidth int) int {
+	if paperWidth == Paper58mm {
+		return 32
+	}
+	return 48
+}
+
+// Alignment of text on the line
+type Alignment byte
+
+const (
+	AlignLeft   Alignment = 0
+	AlignCenter Alignment = 1
+	AlignRight  Alignment = 2
+)
+
+const (
+	esc = 0x1b
+	gs  = 0x1d
+)
+
+// Builder accumulates text and printer commands. Text is printed in the
+// printer's default code page, so anything outside printable ASCII is
+// replaced.
+type Builder struct {
+	buf     bytes.Buffer
+	columns int
+}
+
+// NewBuilder starts a receipt for the given paper width, resetting the
+// printer to its defaults
+func NewBuilder(paperWidth int) *Builder {
+	b := &Builder{columns: Columns(paperWidth)}
+	b.buf.Write([]byte{esc, '@'})
+	return b
+}
+
+// Columns returns the characters per line in normal-size text
+func (b *Builder) Columns() int {
+	return b.columns
+}
+
+// Align sets the alignment of the following lines
+func (b *Builder) Align(a Alignment) *Builder {
+	b.buf.Write([]byte{esc, 'a', byte(a)})
+	return b
+}
+
+// Bold turns emphasised printing on or off
+func (b *Builder) Bold(on bool) *Builder {
+	b.buf.Write([]byte{esc, 'E', boolByte(on)})
+	return b
+}
+
+// DoubleSize turns double width and height text on or off. Double-size text
+// fits half as many characters on a line.
+func (b *Builder) DoubleSize(on bool) *Builder {
+	size := byte(0x00)
+	if on {
+		size = 0x11
+	}
+	b.buf.Write([]byte{gs, '!', size})
+	return b
+}
+
+// Line prints text followed by a line feed
+func (b *Builder) Line(text string) *Builder {
+	b.buf.WriteString(printable(text))
+	b.buf.WriteByte('\n')
+	return b
+}
+
+// Wrap prints text word-wrapped to the line width
+func (b *Builder) Wrap(text string) *Builder {
+	for _, line := range wrap(printable(text), b.columns) {
+		b.Line(line)
+	}
+	return b
+}
+
+// Pair prints a label on the left and a value on the right of one line,
+// wrapping the label if both don't fit
+func (b *Builder) Pair(label, value string) *Builder {
+	label, value = printable(label), printable(value)
+	space := b.columns - len(value) - 1
+	if space < 1 {
+		return b.Line(label).Line(fmt.Sprintf("%*s", b.columns, value))
+	}
+	lines := wrap(label, space)
+	for _, line := range lines[:len(lines)-1] {
+		b.Line(line)
+	}
+	last := lines[len(lines)-1]
+	return b.Line(last + strings.Repeat(" ", b.columns-len(last)-len(value)) + value)
+}
+
+// Rule prints a full-width separator line
+func (b *Builder) Rule() *Builder {
+	return b.Line(strings.Repeat("-", b.columns))
+}
+
+// QRCode prints data as a QR code using the printer's built-in encoder.
+// moduleSize is the width of one QR module in dots, from 1 to 16.
+func (b *Builder) QRCode(data string, moduleSize byte) *Builder {
+	qr := func(fn byte, params ...byte) {
+		length := len(params) + 2
+		b.buf.Write([]byte{gs, '(', 'k', byte(length), byte(length >> 8), 49, fn})
+		b.buf.Write(params)
+	}
+	qr(65, 50, 0)                          // model 2
+	qr(67, moduleSize)                     // module size
+	qr(69, 49)                             // error correction level M
+	qr(80, append([]byte{48}, data...)...) // store the data
+	qr(81, 48)                             // print it
+	return b
+}
+
+// Feed advances the paper by n lines
+func (b *Builder) Feed(n int) *Builder {
+	b.buf.Write([]byte{esc, 'd', byte(n)})
+	return b
+}
+
+// Cut feeds the paper past the cutter and makes a partial cut
+func (b *Builder) Cut() *Builder {
+	b.buf.Write([]byte{gs, 'V', 66, 3})
+	return b
+}
+
+// Bytes returns the command stream
+func (b *Builder) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// DefaultPort is the port printers listen on for raw jobs
+const DefaultPort = "9100"
+
+// PrinterAddress returns the host:port to reach a printer at address, a
+// host name or IPv4 or IPv6 address with an optional port. DefaultPort is
+// used when no port is given.
+func PrinterAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	// A bare IPv6 address has colons but no port
+	if ip := net.ParseIP(strings.Trim(address, "[]")); ip != nil {
+		return net.JoinHostPort(ip.String(), DefaultPort), nil
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	var addrErr *net.AddrError
+	if errors.As(err, &addrErr) && addrErr.Err == "missing port in address" {
+		host, port, err = address, DefaultPort, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if host == "" || strings.ContainsAny(host, " /") {
+		return "", fmt.Errorf("invalid printer host %q", host)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("invalid printer port %q", port)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// Send writes a command stream to a network printer listening for raw jobs,
+// usually on TCP port 9100
+func Send(address string, data []byte, timeout time.Duration) error {
+	address, err := PrinterAddress(address)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	_, err = conn.Write(data)
+	return err
+}
+
+func boolByte(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
+
+var asciiReplacer = strings.NewReplacer(
+	"₹", "Rs.", "–", "-", "—", "-", "‘", "'", "’", "'", "“", `"`, "”", `"`, "…", "...",
+)
+
+// printable replaces characters the printer's code page may not have
+func printable(text string) string {
+	text = asciiReplacer.Replace(text)
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return ' '
+		}
+		if r < 0x20 || r > 0x7e {
+			return '?'
+		}
+		return r
+	}, text)
+}
+
+// wrap breaks text into lines of at most width characters, splitting words
+// only when a single word is longer than a line
+func wrap(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for len(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, word[:width])
+			word = word[width:]
+		}
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"trinity-lodge/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AvailabilityHandler struct {
+	service *services.AvailabilityService
+}
+
+func NewAvailabilityHandler(service *services.AvailabilityService) *AvailabilityHandler {
+	return &AvailabilityHandler{service: service}
+}
+
+// Search lists the rooms free from ?from up to the ?to checkout date by room
+// type, or only of ?type, quoted for ?occupancy guests and rate plan ?code
+func (h *AvailabilityHandler) Search(c *gin.Context) {
+	var roomTypeID *uuid.UUID
+	if value := c.Query("type"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room type ID"})
+			return
+		}
+		roomTypeID = &id
+	}
+	occupancy, ok := occupancyQuery(c)
+	if !ok {
+		return
+	}
+
+	availability, err := h.service.Search(c.Query("from"), c.Query("to"), roomTypeID, occupancy, c.Query("code"))
+	if err != nil {
+		writePricingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
@@ -1,7 +1,14 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"trinity-lodge/internal/invoice"
 	"trinity-lodge/internal/models"
 	"trinity-lodge/internal/services"
 
@@ -17,18 +24,35 @@ func NewBillHandler(service *services.BillService) *BillHandler {
 	return &BillHandler{service: service}
 }
 
+// CreateBillRequest carries the client-supplied parts of a bill. Subtotal,
+// tax and total are always computed by the server from the line items.
+// TaxRate is the default rate for lines that don't carry their own.
 type CreateBillRequest struct {
-	CustomerID     uuid.UUID               `json:"customer_id" binding:"required"`
-	ReservationID  *uuid.UUID              `json:"reservation_id"`
-	BillType       models.BillType         `json:"bill_type" binding:"required"`
-	BillDate       string                  `json:"bill_date" binding:"required"`
-	IsGSTBill      bool                    `json:"is_gst_bill"`
-	Subtotal       float64                 `json:"subtotal"`
-	TaxAmount      float64                 `json:"tax_amount"`
-	DiscountAmount float64                 `json:"discount_amount"`
-	TotalAmount    float64                 `json:"total_amount" binding:"required"`
-	Status         models.BillStatus       `json:"status"`
-	LineItems      []models.BillLineItem   `json:"line_items"`
+	CustomerID     uuid.UUID             `json:"customer_id" binding:"required"`
+	ReservationID  *uuid.UUID            `json:"reservation_id"`
+	BillType       models.BillType       `json:"bill_type" binding:"required"`
+	BillDate       string                `json:"bill_date" binding:"required"`
+	IsGSTBill      bool                  `json:"is_gst_bill"`
+	TaxRate        float64               `json:"tax_rate"`
+	PlaceOfSupply  string                `json:"place_of_supply"`
+	DiscountAmount models.Money          `json:"discount_amount"`
+	Status         models.BillStatus     `json:"status"`
+	LineItems      []BillLineItemRequest `json:"line_items"`
+}
+
+// BillLineItemRequest is a line item as sent by the client. Amount is only
+// read from clients that don't send a quantity and unit price.
+type BillLineItemRequest struct {
+	ItemType           models.LineItemType `json:"item_type"`
+	Description        string              `json:"description"`
+	HSNSACCode         string              `json:"hsn_sac_code"`
+	Quantity           float64             `json:"quantity"`
+	UnitPrice          models.Money        `json:"unit_price"`
+	DiscountAmount     models.Money        `json:"discount_amount"`
+	TaxRate            *float64            `json:"tax_rate"`
+	Amount             models.Money        `json:"amount"`
+	CatalogItemID      *uuid.UUID          `json:"catalog_item_id"`
+	OriginalLineItemID *uuid.UUID          `json:"original_line_item_id"` // notes only: the invoice line adjusted
 }
 
 func (h *BillHandler) Create(c *gin.Context) {
@@ -47,15 +71,17 @@ func (h *BillHandler) Create(c *gin.Context) {
 		BillType:       req.BillType,
 		BillDate:       req.BillDate,
 		IsGSTBill:      req.IsGSTBill,
-		Subtotal:       req.Subtotal,
-		TaxAmount:      req.TaxAmount,
+		PlaceOfSupply:  req.PlaceOfSupply,
 		DiscountAmount: req.DiscountAmount,
-		TotalAmount:    req.TotalAmount,
 		Status:         req.Status,
 		GeneratedBy:    userID.(uuid.UUID),
 	}
 
-	if err := h.service.CreateBill(bill, req.LineItems); err != nil {
+	if err := h.service.CreateBill(bill, toLineItems(req.LineItems, req.TaxRate)); err != nil {
+		if isBillValidationError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -63,6 +89,109 @@ func (h *BillHandler) Create(c *gin.Context) {
 	c.JSON(http.StatusCreated, bill)
 }
 
+// CreateRoomBillRequest holds the optional overrides for generating a room
+// bill from a reservation. deposit_leftover is CREDIT (the default) or
+// REFUND, for advance deposits the bill doesn't use up.
+type CreateRoomBillRequest struct {
+	CheckoutDate    string                 `json:"checkout_date"`
+	BillDate        string                 `json:"bill_date"`
+	NightlyRate     *models.Money          `json:"nightly_rate" binding:"omitempty,gte=0"`
+	IsGSTBill       bool                   `json:"is_gst_bill"`
+	PlaceOfSupply   string                 `json:"place_of_supply"`
+	DepositLeftover models.DepositLeftover `json:"deposit_leftover"`
+}
+
+func (h *BillHandler) CreateFromReservation(c *gin.Context) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		return
+	}
+
+	var req CreateRoomBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	bill, err := h.service.CreateRoomBillFromReservation(reservationID, req.roomBillOptions(userID.(uuid.UUID)))
+	if err != nil {
+		writeRoomBillError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, bill)
+}
+
+func (req CreateRoomBillRequest) roomBillOptions(generatedBy uuid.UUID) services.RoomBillOptions {
+	return services.RoomBillOptions{
+		CheckoutDate:    req.CheckoutDate,
+		BillDate:        req.BillDate,
+		NightlyRate:     req.NightlyRate,
+		IsGSTBill:       req.IsGSTBill,
+		PlaceOfSupply:   req.PlaceOfSupply,
+		GeneratedBy:     generatedBy,
+		DepositLeftover: req.DepositLeftover,
+	}
+}
+
+// writeRoomBillError maps errors from billing a reservation to responses
+func writeRoomBillError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrReservationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrReservationAlreadyBilled),
+		errors.Is(err, services.ErrChargesBilled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrReservationNotBillable),
+		errors.Is(err, services.ErrInvalidStayDates),
+		errors.Is(err, services.ErrInvalidDepositLeftover),
+		isBillValidationError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// toLineItems converts request line items to models, giving lines without
+// their own tax rate the bill's default rate
+func toLineItems(items []BillLineItemRequest, defaultTaxRate float64) []models.BillLineItem {
+	lineItems := make([]models.BillLineItem, len(items))
+	for i, item := range items {
+		taxRate := defaultTaxRate
+		if item.TaxRate != nil {
+			taxRate = *item.TaxRate
+		}
+		lineItems[i] = models.BillLineItem{
+			ItemType:       item.ItemType,
+			Description:    item.Description,
+			HSNSACCode:     item.HSNSACCode,
+			Quantity:       item.Quantity,
+			UnitPrice:      item.UnitPrice,
+			DiscountAmount: item.DiscountAmount,
+			TaxRate:        taxRate,
+			Amount:         item.Amount,
+			CatalogItemID:  item.CatalogItemID,
+		}
+	}
+	return lineItems
+}
+
+// isBillValidationError reports whether err was caused by bad input rather
+// than a server-side failure
+func isBillValidationError(err error) bool {
+	return errors.Is(err, services.ErrInvalidDiscount) ||
+		errors.Is(err, services.ErrInvalidTaxRate) ||
+		errors.Is(err, services.ErrInvalidLineItem) ||
+		errors.Is(err, services.ErrInvalidBillStatus) ||
+		errors.Is(err, services.ErrInvalidBillDate) ||
+		errors.Is(err, services.ErrNoTaxSlab) ||
+		errors.Is(err, services.ErrCatalogItemNotFound) ||
+		errors.Is(err, services.ErrCatalogItemInactive)
+}
+
 func (h *BillHandler) GetByID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -79,6 +208,106 @@ func (h *BillHandler) GetByID(c *gin.Context) {
 	c.JSON(http.StatusOK, bill)
 }
 
+// GetPDF returns the bill as a printable PDF invoice
+func (h *BillHandler) GetPDF(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	doc, err := h.service.InvoiceDocument(id)
+	if err != nil {
+		if errors.Is(err, services.ErrBillNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bill not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := invoice.WritePDF(&buf, *doc); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, invoiceFileName(doc.Bill)))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// QR code sizes accepted by GetUPIQR, in pixels
+const (
+	defaultQRSize = 256
+	minQRSize     = 64
+	maxQRSize     = 1024
+)
+
+// GetUPIQR returns a UPI QR code for the bill's outstanding balance, as a
+// PNG by default or as SVG with ?format=svg. ?size sets the width in pixels.
+func (h *BillHandler) GetUPIQR(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	size := defaultQRSize
+	if s := c.Query("size"); s != "" {
+		size, err = strconv.Atoi(s)
+		if err != nil || size < minQRSize || size > maxQRSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("size must be between %d and %d", minQRSize, maxQRSize)})
+			return
+		}
+	}
+	format := strings.ToLower(c.DefaultQuery("format", "png"))
+	if format != "png" && format != "svg" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be png or svg"})
+		return
+	}
+
+	payment, err := h.service.UPIPayment(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrBillNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bill not found"})
+		case errors.Is(err, services.ErrUPINotConfigured):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNothingDue), errors.Is(err, services.ErrBillCancelled):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	var image []byte
+	contentType := "image/png"
+	if format == "svg" {
+		image, err = payment.SVG(size)
+		contentType = "image/svg+xml"
+	} else {
+		image, err = payment.PNG(size)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("X-UPI-Link", payment.Link())
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, contentType, image)
+}
+
+// invoiceFileName turns an invoice number such as GST/25-26/0001 into a
+// file name
+func invoiceFileName(bill *models.Bill) string {
+	if bill.InvoiceNumber == "" {
+		return bill.ID.String()
+	}
+	return strings.NewReplacer("/", "-", "\\", "-", `"`, "").Replace(bill.InvoiceNumber)
+}
+
 func (h *BillHandler) GetByCustomerID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -103,9 +332,123 @@ func (h *BillHandler) Finalize(c *gin.Context) {
 	}
 
 	if err := h.service.FinalizeBill(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, services.ErrBillNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrBillNotDraft):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Bill finalized"})
 }
+
+type CancelBillRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (h *BillHandler) Cancel(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req CancelBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	if err := h.service.CancelBill(id, req.Reason, userID.(uuid.UUID)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrBillNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrBillCancelled),
+			errors.Is(err, services.ErrBillHasPayments),
+			errors.Is(err, services.ErrBillHasNotes):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrCancelReasonMissing):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bill cancelled"})
+}
+
+// CreateNoteRequest describes a credit or debit note against an invoice
+type CreateNoteRequest struct {
+	Reason    string                `json:"reason" binding:"required"`
+	NoteDate  string                `json:"note_date"`
+	TaxRate   *float64              `json:"tax_rate"` // checked against each line's original rate
+	LineItems []BillLineItemRequest `json:"line_items" binding:"required,min=1"`
+}
+
+func (h *BillHandler) CreateCreditNote(c *gin.Context) {
+	h.createNote(c, models.DocumentTypeCreditNote)
+}
+
+func (h *BillHandler) CreateDebitNote(c *gin.Context) {
+	h.createNote(c, models.DocumentTypeDebitNote)
+}
+
+func (h *BillHandler) createNote(c *gin.Context, documentType models.DocumentType) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req CreateNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	lines := make([]services.NoteLine, len(req.LineItems))
+	for i, item := range toLineItems(req.LineItems, 0) {
+		item.OriginalLineItemID = req.LineItems[i].OriginalLineItemID
+		lines[i] = services.NoteLine{Item: item, TaxRate: req.TaxRate}
+		if req.LineItems[i].TaxRate != nil {
+			lines[i].TaxRate = req.LineItems[i].TaxRate
+		}
+	}
+
+	note, err := h.service.CreateNote(id, lines, services.NoteOptions{
+		DocumentType: documentType,
+		Reason:       req.Reason,
+		NoteDate:     req.NoteDate,
+		GeneratedBy:  userID.(uuid.UUID),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrBillNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNoteOnDraft),
+			errors.Is(err, services.ErrNoteOnNote),
+			errors.Is(err, services.ErrBillCancelled),
+			errors.Is(err, services.ErrCreditExceedsAmount):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNoteReasonRequired),
+			errors.Is(err, services.ErrNoteLineNotOnBill),
+			errors.Is(err, services.ErrNoteRateMismatch),
+			isBillValidationError(err):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/repository"
+	"trinity-lodge/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CatalogHandler struct {
+	service *services.CatalogService
+}
+
+func NewCatalogHandler(service *services.CatalogService) *CatalogHandler {
+	return &CatalogHandler{service: service}
+}
+
+// GetAll lists the items still sold, optionally one ?category only, or
+// every item with ?include_inactive=true
+func (h *CatalogHandler) GetAll(c *gin.Context) {
+	filter := repository.CatalogFilter{
+		Category:        models.CatalogCategory(c.Query("category")),
+		IncludeInactive: c.Query("include_inactive") == "true",
+	}
+	if filter.Category != "" && !filter.Category.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidCategory.Error()})
+		return
+	}
+
+	items, err := h.service.GetAll(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *CatalogHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	item, err := h.service.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *CatalogHandler) Create(c *gin.Context) {
+	var item models.CatalogItem
+	if err := c.ShouldBindJSON(&item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item.ID = uuid.New()
+	if err := h.service.Create(&item); err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// UpdateCatalogItemRequest replaces an item's details. is_active is only
+// changed when it is sent; send false to stop selling the item.
+type UpdateCatalogItemRequest struct {
+	Name         string                 `json:"name"`
+	Category     models.CatalogCategory `json:"category"`
+	DefaultPrice models.Money           `json:"default_price"`
+	HSNSACCode   string                 `json:"hsn_sac_code"`
+	TaxRate      float64                `json:"tax_rate"`
+	IsActive     *bool                  `json:"is_active"`
+}
+
+func (h *CatalogHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req UpdateCatalogItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item := models.CatalogItem{
+		ID:           id,
+		Name:         req.Name,
+		Category:     req.Category,
+		DefaultPrice: req.DefaultPrice,
+		HSNSACCode:   req.HSNSACCode,
+		TaxRate:      req.TaxRate,
+	}
+	if err := h.service.Update(&item, req.IsActive); err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *CatalogHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		writeCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Catalog item deleted successfully"})
+}
+
+func writeCatalogError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrCatalogItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCatalogItemExists),
+		errors.Is(err, services.ErrCatalogItemInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidCatalogItem),
+		errors.Is(err, services.ErrInvalidCategory),
+		errors.Is(err, services.ErrInvalidTaxRate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"trinity-lodge/internal/models"
 	"trinity-lodge/internal/services"
@@ -95,3 +96,24 @@ func (h *CustomerHandler) Delete(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Customer deleted successfully"})
 }
+
+// GetCredit returns the customer's credit balance and ledger
+func (h *CustomerHandler) GetCredit(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	account, err := h.service.GetCreditAccount(id)
+	if err != nil {
+		if errors.Is(err, services.ErrCustomerNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
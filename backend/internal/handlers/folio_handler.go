@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type FolioHandler struct {
+	service     *services.FolioService
+	billService *services.BillService
+}
+
+func NewFolioHandler(service *services.FolioService, billService *services.BillService) *FolioHandler {
+	return &FolioHandler{service: service, billService: billService}
+}
+
+func (h *FolioHandler) Get(c *gin.Context) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		return
+	}
+
+	folio, err := h.service.GetFolio(reservationID)
+	if err != nil {
+		if errors.Is(err, services.ErrFolioNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, folio)
+}
+
+// PostChargeRequest posts a charge to a reservation's folio. bill_type is
+// FOOD or MANUAL (the default) and decides which bill the charge goes on
+// when the folio is split at checkout. A charge for a catalog_item_id takes
+// the item's price, tax and bill type.
+type PostChargeRequest struct {
+	CatalogItemID *uuid.UUID      `json:"catalog_item_id"`
+	BillType      models.BillType `json:"bill_type"`
+	ChargeDate    string          `json:"charge_date"`
+	Description   string          `json:"description"`
+	HSNSACCode    string          `json:"hsn_sac_code"`
+	Quantity      float64         `json:"quantity"`
+	UnitPrice     models.Money    `json:"unit_price"`
+	TaxRate       float64         `json:"tax_rate"`
+}
+
+func (h *FolioHandler) PostCharge(c *gin.Context) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		return
+	}
+
+	var req PostChargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	charge := models.FolioCharge{
+		ID:            uuid.New(),
+		CatalogItemID: req.CatalogItemID,
+		BillType:      req.BillType,
+		ChargeDate:    req.ChargeDate,
+		Description:   req.Description,
+		HSNSACCode:    req.HSNSACCode,
+		Quantity:      req.Quantity,
+		UnitPrice:     req.UnitPrice,
+		TaxRate:       req.TaxRate,
+		PostedBy:      userID.(uuid.UUID),
+	}
+	if err := h.service.PostCharge(reservationID, &charge); err != nil {
+		writeFolioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, charge)
+}
+
+func (h *FolioHandler) RemoveCharge(c *gin.Context) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		return
+	}
+	chargeID, err := uuid.Parse(c.Param("chargeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid charge ID"})
+		return
+	}
+
+	if err := h.service.RemoveCharge(reservationID, chargeID); err != nil {
+		writeFolioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Charge removed"})
+}
+
+// FolioCheckoutRequest bills a reservation's stay and folio. With split, the
+// folio's charges go on separate FOOD and MANUAL bills instead of the ROOM
+// bill.
+type FolioCheckoutRequest struct {
+	CreateRoomBillRequest
+	Split bool `json:"split"`
+}
+
+func (h *FolioHandler) Checkout(c *gin.Context) {
+	reservationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		return
+	}
+
+	var req FolioCheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	bills, err := h.billService.CheckoutFolio(reservationID, req.roomBillOptions(userID.(uuid.UUID)), req.Split)
+	if err != nil {
+		writeRoomBillError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, bills)
+}
+
+func writeFolioError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrReservationNotFound),
+		errors.Is(err, services.ErrFolioNotFound),
+		errors.Is(err, services.ErrFolioChargeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidFolioCharge),
+		errors.Is(err, services.ErrInvalidChargeType),
+		errors.Is(err, services.ErrInvalidChargeDate),
+		errors.Is(err, services.ErrInvalidTaxRate),
+		errors.Is(err, services.ErrCatalogItemNotFound),
+		errors.Is(err, services.ErrCatalogItemInactive):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrReservationNotOpen),
+		errors.Is(err, services.ErrFolioClosed),
+		errors.Is(err, services.ErrFolioChargeBilled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
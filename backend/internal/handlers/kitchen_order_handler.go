@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type KitchenOrderHandler struct {
+	service *services.KitchenOrderService
+}
+
+func NewKitchenOrderHandler(service *services.KitchenOrderService) *KitchenOrderHandler {
+	return &KitchenOrderHandler{service: service}
+}
+
+// GetAll lists orders newest first, or only those with ?status=OPEN, CLOSED
+// or CANCELLED
+func (h *KitchenOrderHandler) GetAll(c *gin.Context) {
+	orders, err := h.service.GetAll(models.KitchenOrderStatus(c.Query("status")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+func (h *KitchenOrderHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	order, err := h.service.GetByID(id)
+	if err != nil {
+		writeKitchenOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CreateKitchenOrderRequest opens an order for a table_number or a room_id
+type CreateKitchenOrderRequest struct {
+	TableNumber string     `json:"table_number"`
+	RoomID      *uuid.UUID `json:"room_id"`
+}
+
+func (h *KitchenOrderHandler) Create(c *gin.Context) {
+	var req CreateKitchenOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	order := &models.KitchenOrder{
+		ID:          uuid.New(),
+		TableNumber: req.TableNumber,
+		RoomID:      req.RoomID,
+		CreatedBy:   userID.(uuid.UUID),
+	}
+	if err := h.service.CreateOrder(order); err != nil {
+		writeKitchenOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// AddKitchenOrderItemRequest adds a catalog item to an order, with an
+// optional note for the kitchen such as "less spicy"
+type AddKitchenOrderItemRequest struct {
+	CatalogItemID uuid.UUID `json:"catalog_item_id" binding:"required"`
+	Quantity      float64   `json:"quantity"`
+	Note          string    `json:"note"`
+}
+
+func (h *KitchenOrderHandler) AddItem(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req AddKitchenOrderItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item := &models.KitchenOrderItem{
+		ID:            uuid.New(),
+		CatalogItemID: req.CatalogItemID,
+		Quantity:      req.Quantity,
+		Note:          req.Note,
+	}
+	if err := h.service.AddItem(orderID, item); err != nil {
+		writeKitchenOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+func (h *KitchenOrderHandler) RemoveItem(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	itemID, err := uuid.Parse(c.Param("itemId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	if err := h.service.RemoveItem(orderID, itemID); err != nil {
+		writeKitchenOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item removed from the order"})
+}
+
+// KOT returns the order's next kitchen order ticket, with the items not yet
+// sent to the kitchen, as raw ESC/POS without sending it
+func (h *KitchenOrderHandler) KOT(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	paper, ok := paperWidth(c)
+	if !ok {
+		return
+	}
+
+	ticket, err := h.service.PendingKOT(orderID, paper)
+	if err != nil {
+		writeKitchenOrderError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, escposContentType, ticket)
+}
+
+// PrintKOT sends the order's next kitchen order ticket to the kitchen
+// printer and marks its items as sent
+func (h *KitchenOrderHandler) PrintKOT(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	paper, ok := paperWidth(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.service.SendKOT(orderID, paper)
+	if err != nil {
+		writeKitchenOrderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// CloseKitchenOrderRequest says where to charge an order. Room orders go to
+// the room's folio. Table orders go to the folio of reservation_id when it
+// is given, for an in-house guest, and otherwise become a FOOD bill for
+// customer_id.
+type CloseKitchenOrderRequest struct {
+	ReservationID *uuid.UUID        `json:"reservation_id"`
+	CustomerID    *uuid.UUID        `json:"customer_id"`
+	IsGSTBill     bool              `json:"is_gst_bill"`
+	PlaceOfSupply string            `json:"place_of_supply"`
+	Status        models.BillStatus `json:"status"`
+}
+
+func (h *KitchenOrderHandler) Close(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req CloseKitchenOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	order, err := h.service.CloseOrder(orderID, services.CloseOrderOptions{
+		ReservationID: req.ReservationID,
+		CustomerID:    req.CustomerID,
+		IsGSTBill:     req.IsGSTBill,
+		PlaceOfSupply: req.PlaceOfSupply,
+		Status:        req.Status,
+		GeneratedBy:   userID.(uuid.UUID),
+	})
+	if err != nil {
+		writeKitchenOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *KitchenOrderHandler) Cancel(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	order, err := h.service.CancelOrder(orderID)
+	if err != nil {
+		writeKitchenOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func writeKitchenOrderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrKitchenOrderNotFound),
+		errors.Is(err, services.ErrOrderItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrKitchenOrderNotOpen),
+		errors.Is(err, services.ErrOrderItemSent),
+		errors.Is(err, services.ErrNothingToSend),
+		errors.Is(err, services.ErrReservationNotOpen),
+		errors.Is(err, services.ErrFolioClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidOrderTarget),
+		errors.Is(err, services.ErrRoomNotInHouse),
+		errors.Is(err, services.ErrNotKitchenItem),
+		errors.Is(err, services.ErrInvalidOrderQuantity),
+		errors.Is(err, services.ErrKitchenOrderEmpty),
+		errors.Is(err, services.ErrOrderCustomerRequired),
+		errors.Is(err, services.ErrCustomerNotFound),
+		errors.Is(err, services.ErrReservationNotFound),
+		errors.Is(err, services.ErrInvalidPaperWidth),
+		errors.Is(err, services.ErrPrinterNotConfigured),
+		isBillValidationError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPrintFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"trinity-lodge/internal/models"
 	"trinity-lodge/internal/services"
@@ -17,6 +18,19 @@ func NewPaymentHandler(service *services.PaymentService) *PaymentHandler {
 	return &PaymentHandler{service: service}
 }
 
+// CreatePaymentRequest records a payment against a bill. The payment method
+// is matched to the configured methods ignoring case. With
+// allow_overpayment, any amount beyond the balance due is credited to the
+// customer instead of being rejected.
+type CreatePaymentRequest struct {
+	Amount           models.Money         `json:"amount"`
+	PaymentMethod    models.PaymentMethod `json:"payment_method" binding:"required"`
+	PaymentDate      string               `json:"payment_date"`
+	ReferenceNumber  string               `json:"reference_number"`
+	Note             string               `json:"note"`
+	AllowOverpayment bool                 `json:"allow_overpayment"`
+}
+
 func (h *PaymentHandler) Create(c *gin.Context) {
 	billID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -24,23 +38,225 @@ func (h *PaymentHandler) Create(c *gin.Context) {
 		return
 	}
 
-	var payment models.Payment
-	if err := c.ShouldBindJSON(&payment); err != nil {
+	var req CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	payment.ID = uuid.New()
-	payment.BillID = billID
+	payment := models.Payment{
+		ID:              uuid.New(),
+		BillID:          billID,
+		Amount:          req.Amount,
+		PaymentMethod:   req.PaymentMethod,
+		PaymentDate:     req.PaymentDate,
+		ReferenceNumber: req.ReferenceNumber,
+		Note:            req.Note,
+	}
 
-	if err := h.service.CreatePayment(&payment); err != nil {
+	opts := services.PaymentOptions{AllowOverpayment: req.AllowOverpayment}
+	if err := h.service.CreatePayment(&payment, opts); err != nil {
+		writePaymentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// writePaymentError maps errors from recording payments to responses
+func writePaymentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrBillNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bill not found"})
+	case errors.Is(err, services.ErrInvalidPaymentAmount),
+		errors.Is(err, services.ErrUnknownPaymentMethod),
+		errors.Is(err, services.ErrReferenceRequired),
+		errors.Is(err, services.ErrInvalidPaymentDate),
+		errors.Is(err, services.ErrNoPaymentLegs):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrBillNotPayable),
+		errors.Is(err, services.ErrNothingDue),
+		errors.Is(err, services.ErrOverpayment):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// SplitPaymentRequest records several payments on one bill at once, e.g. part
+// cash and part UPI. The legs share the payment date; the request fails as a
+// whole if any leg is invalid.
+type SplitPaymentRequest struct {
+	PaymentDate      string            `json:"payment_date"`
+	Legs             []PaymentLegInput `json:"legs" binding:"required,min=1,dive"`
+	AllowOverpayment bool              `json:"allow_overpayment"`
+}
+
+// PaymentLegInput is one part of a split payment
+type PaymentLegInput struct {
+	Amount          models.Money         `json:"amount"`
+	PaymentMethod   models.PaymentMethod `json:"payment_method" binding:"required"`
+	ReferenceNumber string               `json:"reference_number"`
+	Note            string               `json:"note"`
+}
+
+func (h *PaymentHandler) CreateSplit(c *gin.Context) {
+	billID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bill ID"})
+		return
+	}
+
+	var req SplitPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payments := make([]*models.Payment, len(req.Legs))
+	for i, leg := range req.Legs {
+		payments[i] = &models.Payment{
+			ID:              uuid.New(),
+			Amount:          leg.Amount,
+			PaymentMethod:   leg.PaymentMethod,
+			PaymentDate:     req.PaymentDate,
+			ReferenceNumber: leg.ReferenceNumber,
+			Note:            leg.Note,
+		}
+	}
+
+	opts := services.PaymentOptions{AllowOverpayment: req.AllowOverpayment}
+	if err := h.service.CreateSplitPayment(billID, payments, opts); err != nil {
+		writePaymentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, payments)
+}
+
+// CustomerPaymentRequest records one payment from a customer that settles
+// several of their bills. Without allocations the amount goes to their
+// outstanding bills, oldest first. With allow_overpayment, whatever isn't
+// allocated is credited to the customer instead of being rejected.
+type CustomerPaymentRequest struct {
+	Amount           models.Money         `json:"amount"`
+	PaymentMethod    models.PaymentMethod `json:"payment_method" binding:"required"`
+	PaymentDate      string               `json:"payment_date"`
+	ReferenceNumber  string               `json:"reference_number"`
+	Note             string               `json:"note"`
+	Allocations      []AllocationInput    `json:"allocations" binding:"dive"`
+	AllowOverpayment bool                 `json:"allow_overpayment"`
+}
+
+// AllocationInput is the part of a customer payment applied to one bill
+type AllocationInput struct {
+	BillID uuid.UUID    `json:"bill_id" binding:"required"`
+	Amount models.Money `json:"amount"`
+}
+
+func (h *PaymentHandler) CreateForCustomer(c *gin.Context) {
+	customerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID"})
+		return
+	}
+
+	var req CustomerPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	payment := models.CustomerPayment{
+		ID:              uuid.New(),
+		CustomerID:      customerID,
+		Amount:          req.Amount,
+		PaymentMethod:   req.PaymentMethod,
+		PaymentDate:     req.PaymentDate,
+		ReferenceNumber: req.ReferenceNumber,
+		Note:            req.Note,
+		ReceivedBy:      userID.(uuid.UUID),
+	}
+	allocations := make([]services.Allocation, len(req.Allocations))
+	for i, a := range req.Allocations {
+		allocations[i] = services.Allocation{BillID: a.BillID, Amount: a.Amount}
+	}
+
+	opts := services.PaymentOptions{AllowOverpayment: req.AllowOverpayment}
+	if err := h.service.CreateCustomerPayment(&payment, allocations, opts); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPaymentDate),
+			errors.Is(err, services.ErrDuplicateAllocation),
+			errors.Is(err, services.ErrAllocationExceedsPayment),
+			errors.Is(err, services.ErrBillNotForCustomer):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrUnallocatedAmount),
+			errors.Is(err, services.ErrNoOutstandingBills):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			writePaymentError(c, err)
+		}
 		return
 	}
 
 	c.JSON(http.StatusCreated, payment)
 }
 
+func (h *PaymentHandler) GetByCustomerID(c *gin.Context) {
+	customerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID"})
+		return
+	}
+
+	payments, err := h.service.GetCustomerPayments(customerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
+func (h *PaymentHandler) GetCustomerPayment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	payment, err := h.service.GetCustomerPayment(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer payment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// Search finds payments across all bills by ?reference= (any part of the
+// reference number), ?method=, ?from= and ?to=
+func (h *PaymentHandler) Search(c *gin.Context) {
+	payments, err := h.service.SearchPayments(services.PaymentSearch{
+		Reference: c.Query("reference"),
+		Method:    models.PaymentMethod(c.Query("method")),
+		From:      c.Query("from"),
+		To:        c.Query("to"),
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReportPeriod) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
 func (h *PaymentHandler) GetByBillID(c *gin.Context) {
 	billID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -56,3 +272,62 @@ func (h *PaymentHandler) GetByBillID(c *gin.Context) {
 
 	c.JSON(http.StatusOK, payments)
 }
+
+// RefundPaymentRequest refunds or reverses a payment. amount is required for
+// refunds; a reversal always takes back what is left of the payment.
+type RefundPaymentRequest struct {
+	Type          models.RefundType    `json:"type" binding:"required,oneof=REFUND REVERSAL"`
+	Amount        models.Money         `json:"amount"`
+	PaymentMethod models.PaymentMethod `json:"payment_method"`
+	RefundDate    string               `json:"refund_date"`
+	Reason        string               `json:"reason" binding:"required"`
+}
+
+func (h *PaymentHandler) Refund(c *gin.Context) {
+	billID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bill ID"})
+		return
+	}
+	paymentID, err := uuid.Parse(c.Param("paymentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	var req RefundPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	refund, err := h.service.RefundPayment(billID, paymentID, services.RefundOptions{
+		Type:          req.Type,
+		Amount:        req.Amount,
+		PaymentMethod: req.PaymentMethod,
+		RefundDate:    req.RefundDate,
+		Reason:        req.Reason,
+		CreatedBy:     userID.(uuid.UUID),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPaymentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		case errors.Is(err, services.ErrInvalidRefundType),
+			errors.Is(err, services.ErrRefundReasonRequired),
+			errors.Is(err, services.ErrInvalidPaymentAmount),
+			errors.Is(err, services.ErrUnknownPaymentMethod),
+			errors.Is(err, services.ErrInvalidRefundDate):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrRefundExceedsPayment),
+			errors.Is(err, services.ErrPaymentFullyRefunded):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, refund)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PaymentMethodHandler struct {
+	service *services.PaymentMethodService
+}
+
+func NewPaymentMethodHandler(service *services.PaymentMethodService) *PaymentMethodHandler {
+	return &PaymentMethodHandler{service: service}
+}
+
+// GetAll lists the active payment methods, or every method with
+// ?include_inactive=true
+func (h *PaymentMethodHandler) GetAll(c *gin.Context) {
+	methods, err := h.service.GetAll(c.Query("include_inactive") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, methods)
+}
+
+func (h *PaymentMethodHandler) Create(c *gin.Context) {
+	var method models.PaymentMethodConfig
+	if err := c.ShouldBindJSON(&method); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	method.ID = uuid.New()
+	if err := h.service.Create(&method); err != nil {
+		switch {
+		case errors.Is(err, services.ErrPaymentMethodRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPaymentMethodExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, method)
+}
+
+func (h *PaymentMethodHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var method models.PaymentMethodConfig
+	if err := c.ShouldBindJSON(&method); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	method.ID = id
+	if err := h.service.Update(&method); err != nil {
+		switch {
+		case errors.Is(err, services.ErrPaymentMethodNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPaymentMethodRenamed):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, method)
+}
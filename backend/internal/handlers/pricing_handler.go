@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PricingHandler struct {
+	service *services.PricingService
+}
+
+func NewPricingHandler(service *services.PricingService) *PricingHandler {
+	return &PricingHandler{service: service}
+}
+
+// GetAll lists the rate plans, or only those of ?room_type_id
+func (h *PricingHandler) GetAll(c *gin.Context) {
+	var roomTypeID *uuid.UUID
+	if value := c.Query("room_type_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room type ID"})
+			return
+		}
+		roomTypeID = &id
+	}
+
+	plans, err := h.service.GetRatePlans(roomTypeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+func (h *PricingHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	plan, err := h.service.GetRatePlan(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *PricingHandler) Create(c *gin.Context) {
+	var plan models.RatePlan
+	if err := c.ShouldBindJSON(&plan); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan.ID = uuid.New()
+	if err := h.service.CreateRatePlan(&plan); err != nil {
+		writePricingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// Update replaces a rate plan, including all of its prices
+func (h *PricingHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var plan models.RatePlan
+	if err := c.ShouldBindJSON(&plan); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan.ID = id
+	if err := h.service.UpdateRatePlan(&plan); err != nil {
+		writePricingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *PricingHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.service.DeleteRatePlan(id); err != nil {
+		writePricingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rate plan deleted successfully"})
+}
+
+// Quote prices a stay in a room type night by night, for
+// ?from=&to=&occupancy=&code=. to is the checkout date; occupancy defaults
+// to 1 and code selects a rate plan such as a corporate rate.
+func (h *PricingHandler) Quote(c *gin.Context) {
+	roomTypeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room type ID"})
+		return
+	}
+	occupancy, ok := occupancyQuery(c)
+	if !ok {
+		return
+	}
+
+	quote, err := h.service.Quote(roomTypeID, c.Query("from"), c.Query("to"), occupancy, c.Query("code"))
+	if err != nil {
+		writePricingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+func occupancyQuery(c *gin.Context) (int, bool) {
+	value := c.Query("occupancy")
+	if value == "" {
+		return 0, true
+	}
+	occupancy, err := strconv.Atoi(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidOccupancy.Error()})
+		return 0, false
+	}
+	return occupancy, true
+}
+
+func writePricingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrRatePlanNotFound),
+		errors.Is(err, services.ErrRoomTypeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidRatePlan),
+		errors.Is(err, services.ErrUnknownRatePlanCode),
+		errors.Is(err, services.ErrInvalidQuoteDates),
+		errors.Is(err, services.ErrInvalidStayDates),
+		errors.Is(err, services.ErrInvalidOccupancy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
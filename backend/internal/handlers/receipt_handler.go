@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"trinity-lodge/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// escposContentType is used for raw printer command streams
+const escposContentType = "application/vnd.escpos"
+
+type ReceiptHandler struct {
+	service *services.ReceiptService
+}
+
+func NewReceiptHandler(service *services.ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{service: service}
+}
+
+// Bill returns the bill as an ESC/POS receipt. ?paper=58 or 80 picks the
+// paper width; the default comes from settings.
+func (h *ReceiptHandler) Bill(c *gin.Context) {
+	receipt, ok := h.billReceipt(c)
+	if !ok {
+		return
+	}
+	c.Data(http.StatusOK, escposContentType, receipt)
+}
+
+// PrintBill sends the bill's receipt to the configured printer
+func (h *ReceiptHandler) PrintBill(c *gin.Context) {
+	receipt, ok := h.billReceipt(c)
+	if !ok {
+		return
+	}
+	h.print(c, receipt)
+}
+
+// Payment returns an ESC/POS receipt for one payment made on a bill
+func (h *ReceiptHandler) Payment(c *gin.Context) {
+	receipt, ok := h.paymentReceipt(c)
+	if !ok {
+		return
+	}
+	c.Data(http.StatusOK, escposContentType, receipt)
+}
+
+// PrintPayment sends a payment receipt to the configured printer
+func (h *ReceiptHandler) PrintPayment(c *gin.Context) {
+	receipt, ok := h.paymentReceipt(c)
+	if !ok {
+		return
+	}
+	h.print(c, receipt)
+}
+
+// CustomerPayment returns an ESC/POS receipt for a payment that settled
+// several bills, showing its allocation to each
+func (h *ReceiptHandler) CustomerPayment(c *gin.Context) {
+	receipt, ok := h.customerPaymentReceipt(c)
+	if !ok {
+		return
+	}
+	c.Data(http.StatusOK, escposContentType, receipt)
+}
+
+// PrintCustomerPayment sends a customer payment receipt to the configured
+// printer
+func (h *ReceiptHandler) PrintCustomerPayment(c *gin.Context) {
+	receipt, ok := h.customerPaymentReceipt(c)
+	if !ok {
+		return
+	}
+	h.print(c, receipt)
+}
+
+func (h *ReceiptHandler) billReceipt(c *gin.Context) ([]byte, bool) {
+	billID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bill ID"})
+		return nil, false
+	}
+	paper, ok := paperWidth(c)
+	if !ok {
+		return nil, false
+	}
+
+	receipt, err := h.service.BillReceipt(billID, paper)
+	if err != nil {
+		receiptError(c, err)
+		return nil, false
+	}
+	return receipt, true
+}
+
+func (h *ReceiptHandler) paymentReceipt(c *gin.Context) ([]byte, bool) {
+	billID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bill ID"})
+		return nil, false
+	}
+	paymentID, err := uuid.Parse(c.Param("paymentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return nil, false
+	}
+	paper, ok := paperWidth(c)
+	if !ok {
+		return nil, false
+	}
+
+	receipt, err := h.service.PaymentReceipt(billID, paymentID, paper)
+	if err != nil {
+		receiptError(c, err)
+		return nil, false
+	}
+	return receipt, true
+}
+
+func (h *ReceiptHandler) customerPaymentReceipt(c *gin.Context) ([]byte, bool) {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return nil, false
+	}
+	paper, ok := paperWidth(c)
+	if !ok {
+		return nil, false
+	}
+
+	receipt, err := h.service.CustomerPaymentReceipt(paymentID, paper)
+	if err != nil {
+		receiptError(c, err)
+		return nil, false
+	}
+	return receipt, true
+}
+
+func (h *ReceiptHandler) print(c *gin.Context, receipt []byte) {
+	if err := h.service.Print(receipt); err != nil {
+		switch {
+		case errors.Is(err, services.ErrPrinterNotConfigured):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPrintFailed):
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Receipt sent to printer"})
+}
+
+func paperWidth(c *gin.Context) (int, bool) {
+	paper := c.Query("paper")
+	if paper == "" {
+		return 0, true
+	}
+	width, err := strconv.Atoi(paper)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidPaperWidth.Error()})
+		return 0, false
+	}
+	return width, true
+}
+
+func receiptError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrBillNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bill not found"})
+	case errors.Is(err, services.ErrPaymentNotFound),
+		errors.Is(err, services.ErrCustomerPaymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+	case errors.Is(err, services.ErrInvalidPaperWidth):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
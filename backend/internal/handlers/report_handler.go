@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"trinity-lodge/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	service *services.ReportService
+}
+
+func NewReportHandler(service *services.ReportService) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+func (h *ReportHandler) Revenue(c *gin.Context) {
+	report, err := h.service.Revenue(c.Query("from"), c.Query("to"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReportPeriod) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *ReportHandler) Collections(c *gin.Context) {
+	report, err := h.service.Collections(c.Query("from"), c.Query("to"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReportPeriod) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
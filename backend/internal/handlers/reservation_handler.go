@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"trinity-lodge/internal/models"
 	"trinity-lodge/internal/services"
@@ -26,6 +28,10 @@ func (h *ReservationHandler) Create(c *gin.Context) {
 
 	reservation.ID = uuid.New()
 	if err := h.service.CreateReservation(&reservation); err != nil {
+		if errors.Is(err, services.ErrInvalidOccupancy) || errors.Is(err, services.ErrUnknownRatePlanCode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -81,7 +87,19 @@ func (h *ReservationHandler) Cancel(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.CancelReservation(id); err != nil {
+	var req struct {
+		DepositLeftover models.DepositLeftover `json:"deposit_leftover"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.CancelReservation(id, req.DepositLeftover); err != nil {
+		if errors.Is(err, services.ErrInvalidDepositLeftover) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -111,3 +129,79 @@ func (h *ReservationHandler) Checkout(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Checkout successful"})
 }
+
+// CreateDepositRequest records an advance paid against a reservation. The
+// payment method is matched to the configured methods ignoring case.
+type CreateDepositRequest struct {
+	Amount          models.Money         `json:"amount"`
+	PaymentMethod   models.PaymentMethod `json:"payment_method" binding:"required"`
+	DepositDate     string               `json:"deposit_date"`
+	ReferenceNumber string               `json:"reference_number"`
+	Note            string               `json:"note"`
+}
+
+func (h *ReservationHandler) CreateDeposit(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req CreateDepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	deposit := models.ReservationDeposit{
+		ID:              uuid.New(),
+		ReservationID:   id,
+		Amount:          req.Amount,
+		PaymentMethod:   req.PaymentMethod,
+		DepositDate:     req.DepositDate,
+		ReferenceNumber: req.ReferenceNumber,
+		Note:            req.Note,
+		ReceivedBy:      userID.(uuid.UUID),
+	}
+	if err := h.service.AddDeposit(&deposit); err != nil {
+		switch {
+		case errors.Is(err, services.ErrReservationNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reservation not found"})
+		case errors.Is(err, services.ErrInvalidPaymentAmount),
+			errors.Is(err, services.ErrUnknownPaymentMethod),
+			errors.Is(err, services.ErrReferenceRequired),
+			errors.Is(err, services.ErrInvalidDepositDate):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrReservationNotBillable),
+			errors.Is(err, services.ErrReservationAlreadyBilled):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, deposit)
+}
+
+func (h *ReservationHandler) GetDeposits(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	deposits, err := h.service.GetDeposits(id)
+	if err != nil {
+		if errors.Is(err, services.ErrReservationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reservation not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deposits)
+}
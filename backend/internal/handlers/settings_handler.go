@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"trinity-lodge/internal/models"
 	"trinity-lodge/internal/services"
@@ -16,13 +17,30 @@ func NewSettingsHandler(service *services.SettingsService) *SettingsHandler {
 	return &SettingsHandler{service: service}
 }
 
+// SettingsRequest holds the editable settings. Number series left empty or
+// zero keep their current values. Next numbers apply to the current
+// financial year.
 type SettingsRequest struct {
-	LodgeName string `json:"lodge_name" binding:"required"`
-	Address   string `json:"address"`
-	Phone     string `json:"phone"`
-	GSTNumber string `json:"gst_number"`
-	StateName string `json:"state_name"`
-	StateCode string `json:"state_code"`
+	LodgeName               string              `json:"lodge_name" binding:"required"`
+	Address                 string              `json:"address"`
+	Phone                   string              `json:"phone"`
+	GSTNumber               string              `json:"gst_number"`
+	StateName               string              `json:"state_name"`
+	StateCode               string              `json:"state_code"`
+	GSTInvoicePrefix        string              `json:"gst_invoice_prefix"`
+	GSTInvoiceFormat        string              `json:"gst_invoice_format"`
+	GSTInvoiceNextNumber    int                 `json:"gst_invoice_next_number" binding:"gte=0"`
+	NonGSTInvoicePrefix     string              `json:"non_gst_invoice_prefix"`
+	NonGSTInvoiceFormat     string              `json:"non_gst_invoice_format"`
+	NonGSTInvoiceNextNumber int                 `json:"non_gst_invoice_next_number" binding:"gte=0"`
+	CreditNotePrefix        string              `json:"credit_note_prefix"`
+	CreditNoteFormat        string              `json:"credit_note_format"`
+	CreditNoteNextNumber    int                 `json:"credit_note_next_number" binding:"gte=0"`
+	DebitNotePrefix         string              `json:"debit_note_prefix"`
+	DebitNoteFormat         string              `json:"debit_note_format"`
+	DebitNoteNextNumber     int                 `json:"debit_note_next_number" binding:"gte=0"`
+	RoundingMode            models.RoundingMode `json:"rounding_mode" binding:"omitempty,oneof=NEAREST UP DOWN NONE"`
+	ReceiptPaperWidth       int                 `json:"receipt_paper_width" binding:"omitempty,oneof=58 80"`
 }
 
 func (h *SettingsHandler) Get(c *gin.Context) {
@@ -48,9 +66,81 @@ func (h *SettingsHandler) Save(c *gin.Context) {
 		GSTNumber: req.GSTNumber,
 		StateName: req.StateName,
 		StateCode: req.StateCode,
+
+		GSTInvoiceFormat:        req.GSTInvoiceFormat,
+		GSTInvoicePrefix:        req.GSTInvoicePrefix,
+		GSTInvoiceNextNumber:    req.GSTInvoiceNextNumber,
+		NonGSTInvoiceFormat:     req.NonGSTInvoiceFormat,
+		NonGSTInvoicePrefix:     req.NonGSTInvoicePrefix,
+		NonGSTInvoiceNextNumber: req.NonGSTInvoiceNextNumber,
+		CreditNoteFormat:        req.CreditNoteFormat,
+		CreditNotePrefix:        req.CreditNotePrefix,
+		CreditNoteNextNumber:    req.CreditNoteNextNumber,
+		DebitNoteFormat:         req.DebitNoteFormat,
+		DebitNotePrefix:         req.DebitNotePrefix,
+		DebitNoteNextNumber:     req.DebitNoteNextNumber,
+		RoundingMode:            req.RoundingMode,
+		ReceiptPaperWidth:       req.ReceiptPaperWidth,
 	}
 
 	if err := h.service.Save(settings); err != nil {
+		if errors.Is(err, services.ErrInvalidInvoiceFormat) || errors.Is(err, services.ErrInvoiceNumberLowered) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// PrinterSettingsRequest sets the network printers, e.g. "192.168.1.50" or
+// "192.168.1.50:9100". Leave an address empty to turn that printer off.
+type PrinterSettingsRequest struct {
+	ReceiptPrinterAddress string `json:"receipt_printer_address"`
+	KitchenPrinterAddress string `json:"kitchen_printer_address"`
+}
+
+func (h *SettingsHandler) SavePrinters(c *gin.Context) {
+	var req PrinterSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.service.SavePrinters(req.ReceiptPrinterAddress, req.KitchenPrinterAddress)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPrinterAddress) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UPISettingsRequest is the UPI address bill QR codes pay and its payee name
+type UPISettingsRequest struct {
+	UPIVPA       string `json:"upi_vpa"`
+	UPIPayeeName string `json:"upi_payee_name"`
+}
+
+func (h *SettingsHandler) SaveUPI(c *gin.Context) {
+	var req UPISettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.service.SaveUPI(req.UPIVPA, req.UPIPayeeName)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidUPIVPA) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TaxSlabHandler struct {
+	service *services.TaxSlabService
+}
+
+func NewTaxSlabHandler(service *services.TaxSlabService) *TaxSlabHandler {
+	return &TaxSlabHandler{service: service}
+}
+
+func (h *TaxSlabHandler) Create(c *gin.Context) {
+	var slab models.TaxSlab
+	if err := c.ShouldBindJSON(&slab); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slab.ID = uuid.New()
+	if err := h.service.CreateTaxSlab(&slab); err != nil {
+		if errors.Is(err, services.ErrInvalidTaxSlab) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, slab)
+}
+
+func (h *TaxSlabHandler) GetAll(c *gin.Context) {
+	slabs, err := h.service.GetAllTaxSlabs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, slabs)
+}
+
+func (h *TaxSlabHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var slab models.TaxSlab
+	if err := c.ShouldBindJSON(&slab); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slab.ID = id
+	if err := h.service.UpdateTaxSlab(&slab); err != nil {
+		if errors.Is(err, services.ErrInvalidTaxSlab) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, slab)
+}
+
+func (h *TaxSlabHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.service.DeleteTaxSlab(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tax slab deleted successfully"})
+}
@@ -0,0 +1,426 @@
+// Package invoice renders bills as printable documents.
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/upi"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Document holds everything printed on an invoice. Bill must have its
+// line items, customer and reservation (with room and room type) loaded.
+type Document struct {
+	Bill     *models.Bill
+	Settings *models.Settings
+	// OriginalInvoiceNumber is the invoice a credit or debit note adjusts
+	OriginalInvoiceNumber string
+	// UPIPayment, when set, is printed as a QR code for the balance due
+	UPIPayment *upi.Payment
+	// AdvanceReceived is the part of the bill's amount paid that came from
+	// deposits taken on its reservation
+	AdvanceReceived models.Money
+}
+
+const (
+	pageMargin  = 15.0
+	contentW    = 180.0 // A4 width less both margins
+	lineHeight  = 5.0
+	totalsLabel = 50.0
+	totalsValue = 30.0
+)
+
+type column struct {
+	title string
+	width float64
+	align string
+	value func(index int, item *models.BillLineItem) string
+}
+
+var gstColumns = []column{
+	{"#", 8, "C", func(i int, _ *models.BillLineItem) string { return fmt.Sprint(i + 1) }},
+	{"Description", 58, "L", func(_ int, item *models.BillLineItem) string { return item.Description }},
+	{"HSN/SAC", 18, "C", func(_ int, item *models.BillLineItem) string { return item.HSNSACCode }},
+	{"Qty", 12, "R", func(_ int, item *models.BillLineItem) string { return formatQuantity(item.Quantity) }},
+	{"Rate", 20, "R", func(_ int, item *models.BillLineItem) string { return item.UnitPrice.String() }},
+	{"Discount", 16, "R", func(_ int, item *models.BillLineItem) string { return item.DiscountAmount.String() }},
+	{"Taxable", 22, "R", func(_ int, item *models.BillLineItem) string { return item.Amount.String() }},
+	{"GST %", 12, "R", func(_ int, item *models.BillLineItem) string { return formatQuantity(item.TaxRate) }},
+	{"Tax", 14, "R", func(_ int, item *models.BillLineItem) string { return item.TaxAmount.String() }},
+}
+
+var plainColumns = []column{
+	{"#", 8, "C", func(i int, _ *models.BillLineItem) string { return fmt.Sprint(i + 1) }},
+	{"Description", 92, "L", func(_ int, item *models.BillLineItem) string { return item.Description }},
+	{"Qty", 14, "R", func(_ int, item *models.BillLineItem) string { return formatQuantity(item.Quantity) }},
+	{"Rate", 24, "R", func(_ int, item *models.BillLineItem) string { return item.UnitPrice.String() }},
+	{"Discount", 20, "R", func(_ int, item *models.BillLineItem) string { return item.DiscountAmount.String() }},
+	{"Amount", 22, "R", func(_ int, item *models.BillLineItem) string { return item.Amount.String() }},
+}
+
+// WritePDF renders the document as an A4 PDF. GST bills get a tax invoice
+// layout with HSN/SAC codes, rates and the CGST/SGST or IGST split; other
+// bills get a plain bill of supply.
+func WritePDF(w io.Writer, doc Document) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.SetTitle(documentTitle(doc.Bill)+" "+doc.Bill.InvoiceNumber, true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.AddPage()
+	writeHeader(pdf, tr, doc)
+	writeParties(pdf, tr, doc)
+
+	columns := plainColumns
+	if doc.Bill.IsGSTBill {
+		columns = gstColumns
+	}
+	writeLineItems(pdf, tr, columns, doc.Bill.LineItems)
+	writeTotals(pdf, tr, doc)
+	writeFooter(pdf, tr, doc)
+
+	return pdf.Output(w)
+}
+
+// documentTitle names the document as required on its face
+func documentTitle(bill *models.Bill) string {
+	switch {
+	case bill.DocumentType == models.DocumentTypeCreditNote:
+		return "Credit Note"
+	case bill.DocumentType == models.DocumentTypeDebitNote:
+		return "Debit Note"
+	case bill.IsGSTBill:
+		return "Tax Invoice"
+	default:
+		return "Bill of Supply"
+	}
+}
+
+func writeHeader(pdf *fpdf.Fpdf, tr func(string) string, doc Document) {
+	settings := doc.Settings
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(contentW, 8, tr(settings.LodgeName), "", 1, "C", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 9)
+	if settings.Address != "" {
+		pdf.MultiCell(contentW, 4.5, tr(settings.Address), "", "C", false)
+	}
+	var contact []string
+	if settings.Phone != "" {
+		contact = append(contact, "Phone: "+settings.Phone)
+	}
+	if doc.Bill.IsGSTBill && settings.GSTNumber != "" {
+		contact = append(contact, "GSTIN: "+settings.GSTNumber)
+	}
+	if settings.StateName != "" || settings.StateCode != "" {
+		contact = append(contact, "State: "+stateLabel(settings.StateName, settings.StateCode))
+	}
+	if len(contact) > 0 {
+		pdf.CellFormat(contentW, 4.5, tr(strings.Join(contact, "   |   ")), "", 1, "C", false, 0, "")
+	}
+
+	pdf.Ln(3)
+	pdf.SetFont("Helvetica", "B", 13)
+	title := strings.ToUpper(documentTitle(doc.Bill))
+	switch doc.Bill.Status {
+	case models.BillStatusCancelled:
+		title += " (CANCELLED)"
+	case models.BillStatusDraft:
+		title += " (DRAFT)"
+	}
+	pdf.CellFormat(contentW, 8, tr(title), "TB", 1, "C", false, 0, "")
+	pdf.Ln(2)
+}
+
+func writeParties(pdf *fpdf.Fpdf, tr func(string) string, doc Document) {
+	bill := doc.Bill
+	half := contentW / 2
+	top := pdf.GetY()
+
+	// Billed to, on the left
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(half, lineHeight, "Billed To", "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	if customer := bill.Customer; customer != nil {
+		pdf.CellFormat(half, lineHeight, tr(customer.FullName), "", 2, "L", false, 0, "")
+		if customer.Address != "" {
+			pdf.MultiCell(half, 4.5, tr(customer.Address), "", "L", false)
+		}
+		if customer.Phone != "" {
+			pdf.CellFormat(half, lineHeight, tr("Phone: "+customer.Phone), "", 2, "L", false, 0, "")
+		}
+		if bill.IsGSTBill && customer.GSTNumber != "" {
+			pdf.CellFormat(half, lineHeight, tr("GSTIN: "+customer.GSTNumber), "", 2, "L", false, 0, "")
+		}
+		if customer.StateName != "" || customer.StateCode != "" {
+			pdf.CellFormat(half, lineHeight, tr("State: "+stateLabel(customer.StateName, customer.StateCode)), "", 2, "L", false, 0, "")
+		}
+	}
+	leftBottom := pdf.GetY()
+
+	// Document details, on the right
+	var details [][2]string
+	details = append(details,
+		[2]string{documentTitle(bill) + " No", bill.InvoiceNumber},
+		[2]string{"Date", displayDate(bill.BillDate)},
+	)
+	if doc.OriginalInvoiceNumber != "" {
+		details = append(details, [2]string{"Against Invoice", doc.OriginalInvoiceNumber})
+	}
+	if bill.NoteReason != "" {
+		details = append(details, [2]string{"Reason", bill.NoteReason})
+	}
+	if bill.IsGSTBill && bill.PlaceOfSupply != "" {
+		details = append(details, [2]string{"Place of Supply", bill.PlaceOfSupply})
+		if bill.IsInterState {
+			details = append(details, [2]string{"Supply", "Inter-state"})
+		}
+	}
+	if res := bill.Reservation; res != nil {
+		if res.Room != nil {
+			room := res.Room.RoomNumber
+			if res.Room.Type != nil {
+				room += " - " + res.Room.Type.Name
+			}
+			details = append(details, [2]string{"Room", room})
+		}
+		checkIn := res.CheckInDate
+		if res.ActualCheckInDate != nil {
+			checkIn = *res.ActualCheckInDate
+		}
+		details = append(details, [2]string{"Check-in", displayDate(checkIn)})
+		checkOut := res.ExpectedCheckOutDate
+		if res.ActualCheckOutDate != nil {
+			checkOut = *res.ActualCheckOutDate
+		}
+		if checkOut != "" {
+			details = append(details, [2]string{"Check-out", displayDate(checkOut)})
+		}
+	}
+
+	pdf.SetXY(pageMargin+half, top)
+	for _, detail := range details {
+		pdf.SetX(pageMargin + half)
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.CellFormat(35, lineHeight, tr(detail[0]), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(half-35, lineHeight, tr(detail[1]), "", 1, "L", false, 0, "")
+	}
+
+	if pdf.GetY() < leftBottom {
+		pdf.SetY(leftBottom)
+	}
+	pdf.Ln(4)
+}
+
+func writeLineItems(pdf *fpdf.Fpdf, tr func(string) string, columns []column, items []models.BillLineItem) {
+	writeTableHeader(pdf, columns)
+
+	// Only the description wraps; every other cell is one line tall
+	descWidth := 0.0
+	for _, col := range columns {
+		if col.title == "Description" {
+			descWidth = col.width
+		}
+	}
+
+	_, pageHeight := pdf.GetPageSize()
+	pdf.SetFont("Helvetica", "", 8.5)
+	for i := range items {
+		item := &items[i]
+		lines := pdf.SplitLines([]byte(tr(item.Description)), descWidth)
+		rowHeight := float64(max(len(lines), 1)) * lineHeight
+
+		if pdf.GetY()+rowHeight > pageHeight-pageMargin-10 {
+			pdf.AddPage()
+			writeTableHeader(pdf, columns)
+			pdf.SetFont("Helvetica", "", 8.5)
+		}
+
+		x, y := pdf.GetXY()
+		for _, col := range columns {
+			if col.title == "Description" {
+				pdf.Rect(x, y, col.width, rowHeight, "D")
+				pdf.SetXY(x, y)
+				pdf.MultiCell(col.width, lineHeight, tr(item.Description), "", "L", false)
+			} else {
+				pdf.SetXY(x, y)
+				pdf.CellFormat(col.width, rowHeight, tr(col.value(i, item)), "1", 0, col.align, false, 0, "")
+			}
+			x += col.width
+		}
+		pdf.SetXY(pageMargin, y+rowHeight)
+	}
+	pdf.Ln(2)
+}
+
+func writeTableHeader(pdf *fpdf.Fpdf, columns []column) {
+	pdf.SetFont("Helvetica", "B", 8.5)
+	pdf.SetFillColor(230, 230, 230)
+	for _, col := range columns {
+		pdf.CellFormat(col.width, 7, col.title, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+func writeTotals(pdf *fpdf.Fpdf, tr func(string) string, doc Document) {
+	bill := doc.Bill
+	type row struct {
+		label string
+		value models.Money
+		bold  bool
+	}
+
+	rows := []row{{label: "Subtotal", value: bill.Subtotal}}
+	if bill.DiscountAmount != 0 {
+		rows = append(rows, row{label: "Less: Discount", value: -bill.DiscountAmount})
+	}
+	if bill.IsGSTBill {
+		rows = append(rows, row{label: "Taxable Value", value: bill.Subtotal - bill.DiscountAmount})
+		if bill.IsInterState {
+			rows = append(rows, row{label: "IGST", value: bill.IGSTAmount})
+		} else {
+			rows = append(rows,
+				row{label: "CGST", value: bill.CGSTAmount},
+				row{label: "SGST", value: bill.SGSTAmount},
+			)
+		}
+	}
+	if bill.RoundOff != 0 {
+		rows = append(rows, row{label: "Round Off", value: bill.RoundOff})
+	}
+	rows = append(rows, row{label: "Total (Rs.)", value: bill.TotalAmount, bold: true})
+	if doc.AdvanceReceived != 0 {
+		rows = append(rows, row{label: "Advance Received", value: doc.AdvanceReceived})
+	}
+	if paid := bill.AmountPaid - doc.AdvanceReceived; paid != 0 {
+		rows = append(rows, row{label: "Paid", value: paid})
+	}
+	if bill.AmountPaid != 0 {
+		rows = append(rows, row{label: "Balance Due", value: bill.BalanceDue, bold: true})
+	}
+
+	blockHeight := float64(len(rows)+3) * lineHeight
+	if doc.UPIPayment != nil {
+		blockHeight = max(blockHeight, upiQRSize+12)
+	}
+	_, pageHeight := pdf.GetPageSize()
+	if pdf.GetY()+blockHeight > pageHeight-pageMargin {
+		pdf.AddPage()
+	}
+
+	top := pdf.GetY()
+	qrBottom := top
+	if doc.UPIPayment != nil {
+		qrBottom = writeUPIQR(pdf, tr, doc.UPIPayment, top)
+	}
+
+	pdf.SetY(top)
+	left := pageMargin + contentW - totalsLabel - totalsValue
+	for _, r := range rows {
+		style := ""
+		border := ""
+		if r.bold {
+			style = "B"
+			border = "T"
+		}
+		pdf.SetFont("Helvetica", style, 9)
+		pdf.SetX(left)
+		pdf.CellFormat(totalsLabel, lineHeight+1, tr(r.label), border, 0, "L", false, 0, "")
+		pdf.CellFormat(totalsValue, lineHeight+1, r.value.String(), border, 1, "R", false, 0, "")
+	}
+
+	if pdf.GetY() < qrBottom {
+		pdf.SetY(qrBottom)
+	}
+	pdf.Ln(2)
+	pdf.SetFont("Helvetica", "I", 9)
+	words := bill.AmountInWords
+	if words == "" {
+		words = bill.TotalAmount.InWords()
+	}
+	pdf.MultiCell(contentW, lineHeight, tr("Amount in words: "+words), "", "L", false)
+}
+
+// upiQRSize is the printed width of the UPI QR code in mm
+const upiQRSize = 32.0
+
+// writeUPIQR draws the UPI payment QR code beside the totals and returns the
+// Y position below it. The invoice is still usable if the code can't be
+// drawn, so failures are left out rather than reported.
+func writeUPIQR(pdf *fpdf.Fpdf, tr func(string) string, payment *upi.Payment, top float64) float64 {
+	png, err := payment.PNG(512)
+	if err != nil {
+		return top
+	}
+	options := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("upi-qr", options, bytes.NewReader(png))
+	if pdf.Err() {
+		pdf.ClearError()
+		return top
+	}
+
+	pdf.ImageOptions("upi-qr", pageMargin, top, upiQRSize, upiQRSize, false, options, 0, "")
+	pdf.SetXY(pageMargin, top+upiQRSize)
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.CellFormat(60, 4, tr("Scan to pay Rs. "+payment.Amount.String()), "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(60, 4, tr("UPI: "+payment.VPA), "", 2, "L", false, 0, "")
+	return pdf.GetY()
+}
+
+func writeFooter(pdf *fpdf.Fpdf, tr func(string) string, doc Document) {
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "", 8)
+	if doc.Bill.IsGSTBill && doc.Bill.DocumentType == models.DocumentTypeInvoice {
+		pdf.MultiCell(contentW, 4, "Tax is not payable on reverse charge basis.", "", "L", false)
+	}
+
+	pdf.Ln(10)
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetX(pageMargin + contentW - 70)
+	pdf.CellFormat(70, lineHeight, tr("For "+doc.Settings.LodgeName), "", 1, "R", false, 0, "")
+	pdf.Ln(10)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.SetX(pageMargin + contentW - 70)
+	pdf.CellFormat(70, lineHeight, "Authorised Signatory", "", 1, "R", false, 0, "")
+
+	pdf.SetY(-pageMargin - 5)
+	pdf.SetFont("Helvetica", "I", 7)
+	pdf.CellFormat(contentW, 4, "This is a computer generated document.", "", 0, "C", false, 0, "")
+}
+
+func stateLabel(name, code string) string {
+	switch {
+	case name != "" && code != "":
+		return fmt.Sprintf("%s (%s)", name, code)
+	case name != "":
+		return name
+	default:
+		return code
+	}
+}
+
+// displayDate formats a stored YYYY-MM-DD date as 02 Jan 2006
+func displayDate(date string) string {
+	date = models.DateOnly(date)
+	t, err := time.Parse(models.DateLayout, date)
+	if err != nil {
+		return date
+	}
+	return t.Format("02 Jan 2006")
+}
+
+// formatQuantity prints whole quantities without decimals
+func formatQuantity(q float64) string {
+	s := fmt.Sprintf("%.3f", q)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
@@ -0,0 +1,220 @@
+package invoice
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"trinity-lodge/internal/escpos"
+	"trinity-lodge/internal/models"
+)
+
+// BillReceipt renders a bill as an ESC/POS receipt for 58mm or 80mm paper,
+// with the amount paid against it and the balance still due
+func BillReceipt(doc Document, paperWidth int) []byte {
+	bill := doc.Bill
+	b := escpos.NewBuilder(paperWidth)
+	writeReceiptHeader(b, doc, strings.ToUpper(documentTitle(bill)))
+
+	b.Pair("No", bill.InvoiceNumber)
+	b.Pair("Date", displayDate(bill.BillDate))
+	if doc.OriginalInvoiceNumber != "" {
+		b.Pair("Against", doc.OriginalInvoiceNumber)
+	}
+	writeReceiptGuest(b, bill)
+	b.Rule()
+
+	for _, item := range bill.LineItems {
+		b.Wrap(item.Description)
+		detail := fmt.Sprintf("  %s x %s", formatQuantity(item.Quantity), item.UnitPrice)
+		if item.DiscountAmount != 0 {
+			detail += " -" + item.DiscountAmount.String()
+		}
+		if bill.IsGSTBill && item.TaxRate != 0 {
+			detail += fmt.Sprintf(" @%s%%", formatQuantity(item.TaxRate))
+		}
+		b.Pair(detail, item.Amount.String())
+	}
+	b.Rule()
+
+	b.Pair("Subtotal", bill.Subtotal.String())
+	if bill.DiscountAmount != 0 {
+		b.Pair("Discount", (-bill.DiscountAmount).String())
+	}
+	if bill.IsGSTBill {
+		if bill.IsInterState {
+			b.Pair("IGST", bill.IGSTAmount.String())
+		} else {
+			b.Pair("CGST", bill.CGSTAmount.String())
+			b.Pair("SGST", bill.SGSTAmount.String())
+		}
+	}
+	if bill.RoundOff != 0 {
+		b.Pair("Round Off", bill.RoundOff.String())
+	}
+	b.Bold(true).Pair("TOTAL (Rs.)", bill.TotalAmount.String()).Bold(false)
+
+	if doc.AdvanceReceived != 0 {
+		b.Pair("Advance received", doc.AdvanceReceived.String())
+	}
+	if paid := bill.AmountPaid - doc.AdvanceReceived; paid != 0 {
+		b.Pair("Paid", paid.String())
+	}
+	if bill.AmountPaid != 0 {
+		b.Bold(true).Pair("Balance", bill.BalanceDue.String()).Bold(false)
+	}
+	b.Rule()
+	b.Wrap(bill.TotalAmount.InWords())
+
+	if doc.UPIPayment != nil {
+		b.Feed(1).Align(escpos.AlignCenter)
+		b.QRCode(doc.UPIPayment.Link(), 6)
+		b.Line("Scan to pay Rs. " + doc.UPIPayment.Amount.String())
+		b.Line("UPI: " + doc.UPIPayment.VPA)
+		b.Align(escpos.AlignLeft)
+	}
+
+	return finishReceipt(b)
+}
+
+// PaymentReceipt renders an acknowledgement of one payment against a bill
+func PaymentReceipt(doc Document, payment *models.Payment, paperWidth int) []byte {
+	bill := doc.Bill
+	b := escpos.NewBuilder(paperWidth)
+	writeReceiptHeader(b, doc, "PAYMENT RECEIPT")
+
+	b.Pair("Receipt", strings.ToUpper(payment.ID.String()[:8]))
+	b.Pair("Date", displayDate(payment.PaymentDate))
+	b.Pair("Against", bill.InvoiceNumber)
+	writeReceiptGuest(b, bill)
+	b.Rule()
+
+	b.Pair("Mode", string(payment.PaymentMethod))
+	b.Bold(true).Pair("Amount (Rs.)", payment.Amount.String()).Bold(false)
+	b.Wrap(payment.Amount.InWords())
+	if payment.CustomerPaymentID != nil {
+		b.Pair("Part of receipt", strings.ToUpper(payment.CustomerPaymentID.String()[:8]))
+	}
+	if payment.ExcessCredited != 0 {
+		b.Pair("Credited to account", payment.ExcessCredited.String())
+	}
+	b.Rule()
+
+	b.Pair("Bill Total", bill.TotalAmount.String())
+	b.Pair("Total Paid", bill.AmountPaid.String())
+	b.Bold(true).Pair("Balance", bill.BalanceDue.String()).Bold(false)
+
+	return finishReceipt(b)
+}
+
+// CustomerPaymentReceipt renders an acknowledgement of a payment that
+// settled several bills, listing what was allocated to each. The payment's
+// allocations must be loaded with their bills.
+func CustomerPaymentReceipt(settings *models.Settings, payment *models.CustomerPayment, paperWidth int) []byte {
+	b := escpos.NewBuilder(paperWidth)
+	writeLodgeHeader(b, settings, false, "PAYMENT RECEIPT")
+
+	b.Pair("Receipt", strings.ToUpper(payment.ID.String()[:8]))
+	b.Pair("Date", displayDate(payment.PaymentDate))
+	if payment.Customer != nil {
+		b.Pair("Received from", payment.Customer.FullName)
+	}
+	b.Pair("Mode", string(payment.PaymentMethod))
+	if payment.ReferenceNumber != "" {
+		b.Pair("Ref", payment.ReferenceNumber)
+	}
+	b.Bold(true).Pair("Amount (Rs.)", payment.Amount.String()).Bold(false)
+	b.Wrap(payment.Amount.InWords())
+	b.Rule()
+
+	b.Bold(true).Line("Allocated to").Bold(false)
+	for _, allocation := range payment.Allocations {
+		if allocation.Bill == nil {
+			continue
+		}
+		b.Pair(allocation.Bill.InvoiceNumber, allocation.Amount.String())
+		b.Pair("Balance due", allocation.Bill.BalanceDue.String())
+	}
+	if payment.ExcessCredited != 0 {
+		b.Pair("Credited to account", payment.ExcessCredited.String())
+	}
+
+	return finishReceipt(b)
+}
+
+// KitchenTicket renders a kitchen order ticket (KOT) for the given items of
+// an order, without prices. The order's room must be loaded for room
+// orders.
+func KitchenTicket(order *models.KitchenOrder, items []models.KitchenOrderItem, kotNumber int, printedAt time.Time, paperWidth int) []byte {
+	b := escpos.NewBuilder(paperWidth)
+	b.Align(escpos.AlignCenter)
+	b.Bold(true).DoubleSize(true).Line(fmt.Sprintf("KOT %d", kotNumber)).DoubleSize(false).Bold(false)
+	b.Align(escpos.AlignLeft)
+	b.Rule()
+
+	b.Pair("Order", fmt.Sprintf("#%d", order.OrderNumber))
+	if order.Room != nil {
+		b.Bold(true).Pair("Room", order.Room.RoomNumber).Bold(false)
+	} else {
+		b.Bold(true).Pair("Table", order.TableNumber).Bold(false)
+	}
+	b.Pair("Time", printedAt.Format("02 Jan 2006 15:04"))
+	b.Rule()
+
+	for _, item := range items {
+		b.Bold(true).Wrap(formatQuantity(item.Quantity) + " x " + item.Description).Bold(false)
+		if item.Note != "" {
+			b.Wrap("* " + item.Note)
+		}
+	}
+	b.Rule()
+
+	b.Feed(3).Cut()
+	return b.Bytes()
+}
+
+func writeReceiptHeader(b *escpos.Builder, doc Document, title string) {
+	switch doc.Bill.Status {
+	case models.BillStatusCancelled:
+		title += " - CANCELLED"
+	case models.BillStatusDraft:
+		title += " - DRAFT"
+	}
+	writeLodgeHeader(b, doc.Settings, doc.Bill.IsGSTBill, title)
+}
+
+// writeLodgeHeader prints the lodge's name and contact details above a
+// receipt's title, with its GSTIN when the receipt is for a GST bill
+func writeLodgeHeader(b *escpos.Builder, settings *models.Settings, showGSTIN bool, title string) {
+	b.Align(escpos.AlignCenter)
+	b.Bold(true).DoubleSize(true).Line(settings.LodgeName).DoubleSize(false).Bold(false)
+	if settings.Address != "" {
+		b.Wrap(settings.Address)
+	}
+	if settings.Phone != "" {
+		b.Line("Ph: " + settings.Phone)
+	}
+	if showGSTIN && settings.GSTNumber != "" {
+		b.Line("GSTIN: " + settings.GSTNumber)
+	}
+	b.Feed(1)
+
+	b.Bold(true).Wrap(title).Bold(false)
+	b.Align(escpos.AlignLeft)
+	b.Rule()
+}
+
+func writeReceiptGuest(b *escpos.Builder, bill *models.Bill) {
+	if bill.Customer != nil {
+		b.Pair("Guest", bill.Customer.FullName)
+	}
+	if res := bill.Reservation; res != nil && res.Room != nil {
+		b.Pair("Room", res.Room.RoomNumber)
+	}
+}
+
+func finishReceipt(b *escpos.Builder) []byte {
+	b.Feed(1)
+	b.Align(escpos.AlignCenter).Line("Thank you! Visit again.")
+	b.Feed(3).Cut()
+	return b.Bytes()
+}
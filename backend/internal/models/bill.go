@@ -9,6 +9,8 @@ import (
 
 type BillType string
 type BillStatus string
+type DocumentType string
+type LineItemType string
 
 const (
 	BillTypeRoom   BillType = "ROOM"
@@ -16,46 +18,130 @@ const (
 	BillTypeFood   BillType = "FOOD"
 	BillTypeManual BillType = "MANUAL"
 
-	BillStatusDraft     BillStatus = "DRAFT"
-	BillStatusFinalized BillStatus = "FINALIZED"
-	BillStatusPaid      BillStatus = "PAID"
-	BillStatusUnpaid    BillStatus = "UNPAID"
+	// Issued bills move between UNPAID, PARTIALLY_PAID and PAID as payments
+	// come in. FINALIZED is the status of issued credit notes, which are
+	// never paid.
+	BillStatusDraft         BillStatus = "DRAFT"
+	BillStatusFinalized     BillStatus = "FINALIZED"
+	BillStatusUnpaid        BillStatus = "UNPAID"
+	BillStatusPartiallyPaid BillStatus = "PARTIALLY_PAID"
+	BillStatusPaid          BillStatus = "PAID"
+	BillStatusCancelled     BillStatus = "CANCELLED"
+
+	// Corrections to an issued invoice are made with credit and debit notes
+	// that reference it, never by editing the invoice itself
+	DocumentTypeInvoice    DocumentType = "INVOICE"
+	DocumentTypeCreditNote DocumentType = "CREDIT_NOTE"
+	DocumentTypeDebitNote  DocumentType = "DEBIT_NOTE"
+
+	LineItemTypeRoom  LineItemType = "ROOM"
+	LineItemTypeOther LineItemType = "OTHER"
+
+	// SACRoomAccommodation is the GST service accounting code for hotel stays
+	SACRoomAccommodation = "996311"
 )
 
 type Bill struct {
-	ID             uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
-	CustomerID     uuid.UUID    `gorm:"type:uuid;not null" json:"customer_id"`
-	Customer       *Customer    `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
-	ReservationID  *uuid.UUID   `gorm:"type:uuid" json:"reservation_id"`
-	Reservation    *Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
-	BillType       BillType     `gorm:"type:varchar(20);not null" json:"bill_type"`
-	BillDate       string       `gorm:"type:date;not null" json:"bill_date"`
-	InvoiceNumber  string       `gorm:"type:varchar(50)" json:"invoice_number"`
-	IsGSTBill      bool         `gorm:"default:false" json:"is_gst_bill"`
-	Subtotal       float64      `gorm:"not null;default:0" json:"subtotal"`
-	TaxAmount      float64      `gorm:"not null;default:0" json:"tax_amount"`
-	DiscountAmount float64      `gorm:"not null;default:0" json:"discount_amount"`
-	TotalAmount    float64      `gorm:"not null;default:0" json:"total_amount"`
-	Status         BillStatus   `gorm:"type:varchar(20);not null;default:'DRAFT'" json:"status"`
-	GeneratedBy    uuid.UUID    `gorm:"type:uuid;not null" json:"generated_by"`
-	CreatedAt      time.Time    `json:"created_at"`
-	UpdatedAt      time.Time    `json:"updated_at"`
+	ID             uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
+	CustomerID     uuid.UUID      `gorm:"type:uuid;not null" json:"customer_id"`
+	Customer       *Customer      `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	ReservationID  *uuid.UUID     `gorm:"type:uuid" json:"reservation_id"`
+	Reservation    *Reservation   `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
+	BillType       BillType       `gorm:"type:varchar(20);not null" json:"bill_type"`
+	DocumentType   DocumentType   `gorm:"type:varchar(20);not null;default:'INVOICE'" json:"document_type"`
+	OriginalBillID *uuid.UUID     `gorm:"type:uuid;index" json:"original_bill_id"`
+	NoteReason     string         `gorm:"type:text" json:"note_reason,omitempty"`
+	BillDate       string         `gorm:"type:date;not null" json:"bill_date"`
+	InvoiceNumber  string         `gorm:"type:varchar(50)" json:"invoice_number"`
+	IsGSTBill      bool           `gorm:"default:false" json:"is_gst_bill"`
+	PlaceOfSupply  string         `gorm:"type:varchar(10)" json:"place_of_supply"`
+	IsInterState   bool           `gorm:"default:false" json:"is_inter_state"`
+	Subtotal       Money          `gorm:"not null;default:0" json:"subtotal"`
+	TaxAmount      Money          `gorm:"not null;default:0" json:"tax_amount"`
+	CGSTAmount     Money          `gorm:"not null;default:0" json:"cgst_amount"`
+	SGSTAmount     Money          `gorm:"not null;default:0" json:"sgst_amount"`
+	IGSTAmount     Money          `gorm:"not null;default:0" json:"igst_amount"`
+	DiscountAmount Money          `gorm:"not null;default:0" json:"discount_amount"`
+	RoundOff       Money          `gorm:"not null;default:0" json:"round_off"`
+	TotalAmount    Money          `gorm:"not null;default:0" json:"total_amount"`
+	AmountInWords  string         `gorm:"-" json:"amount_in_words"`
+	AmountPaid     Money          `gorm:"not null;default:0" json:"amount_paid"`
+	BalanceDue     Money          `gorm:"not null;default:0" json:"balance_due"`
+	Status         BillStatus     `gorm:"type:varchar(20);not null;default:'DRAFT'" json:"status"`
+	GeneratedBy    uuid.UUID      `gorm:"type:uuid;not null" json:"generated_by"`
+	CancelledAt    *time.Time     `json:"cancelled_at,omitempty"`
+	CancelledBy    *uuid.UUID     `gorm:"type:uuid" json:"cancelled_by,omitempty"`
+	CancelReason   string         `gorm:"type:text" json:"cancel_reason,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
 	LineItems      []BillLineItem `gorm:"foreignKey:BillID" json:"line_items,omitempty"`
+	Notes          []Bill         `gorm:"foreignKey:OriginalBillID" json:"notes,omitempty"`
 }
 
 func (b *Bill) BeforeCreate(tx *gorm.DB) error {
 	if b.ID == uuid.Nil {
 		b.ID = uuid.New()
 	}
+	if b.DocumentType == "" {
+		b.DocumentType = DocumentTypeInvoice
+	}
 	return nil
 }
 
+// AfterFind spells out the total for display
+func (b *Bill) AfterFind(tx *gorm.DB) error {
+	b.AmountInWords = b.TotalAmount.InWords()
+	return nil
+}
+
+// IsLocked reports whether the bill's amounts and line items are frozen.
+// Only drafts may be edited; everything else is corrected through notes.
+func (b *Bill) IsLocked() bool {
+	return b.Status != BillStatusDraft
+}
+
+// ApplyPayments records what has been paid on the bill and what credit
+// notes have taken off it, and moves an issued bill to UNPAID,
+// PARTIALLY_PAID or PAID to match. A negative balance is owed to the guest.
+// Cancelled bills and credit notes have nothing due.
+func (b *Bill) ApplyPayments(paid, credited Money) {
+	b.AmountPaid = paid
+	if b.Status == BillStatusCancelled || b.DocumentType == DocumentTypeCreditNote {
+		b.BalanceDue = 0
+		return
+	}
+
+	b.BalanceDue = b.TotalAmount - credited - paid
+	if b.Status == BillStatusDraft {
+		return
+	}
+	switch {
+	case b.BalanceDue <= 0:
+		b.Status = BillStatusPaid
+	case paid > 0:
+		b.Status = BillStatusPartiallyPaid
+	default:
+		b.Status = BillStatusUnpaid
+	}
+}
+
+// BillLineItem is one charge on a bill. Amount is the taxable value of the
+// line: quantity times unit price, less the line discount.
 type BillLineItem struct {
-	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	BillID      uuid.UUID `gorm:"type:uuid;not null" json:"bill_id"`
-	Description string    `gorm:"not null" json:"description"`
-	Amount      float64   `gorm:"not null" json:"amount"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID                 uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	BillID             uuid.UUID    `gorm:"type:uuid;not null" json:"bill_id"`
+	ItemType           LineItemType `gorm:"type:varchar(20);not null;default:'OTHER'" json:"item_type"`
+	Description        string       `gorm:"not null" json:"description"`
+	HSNSACCode         string       `gorm:"type:varchar(10)" json:"hsn_sac_code"`
+	Quantity           float64      `gorm:"not null;default:1" json:"quantity"`
+	UnitPrice          Money        `gorm:"not null;default:0" json:"unit_price"`
+	DiscountAmount     Money        `gorm:"not null;default:0" json:"discount_amount"`
+	Amount             Money        `gorm:"not null" json:"amount"`
+	TaxRate            float64      `gorm:"not null;default:0" json:"tax_rate"`
+	TaxAmount          Money        `gorm:"not null;default:0" json:"tax_amount"`
+	CatalogItemID      *uuid.UUID   `gorm:"type:uuid;index" json:"catalog_item_id,omitempty"`       // where the price and tax were copied from
+	OriginalLineItemID *uuid.UUID   `gorm:"type:uuid;index" json:"original_line_item_id,omitempty"` // the invoice line a note line adjusts
+	CreatedAt          time.Time    `json:"created_at"`
 }
 
 func (bli *BillLineItem) BeforeCreate(tx *gorm.DB) error {
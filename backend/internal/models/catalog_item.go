@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CatalogCategory groups catalog items for the front desk and the kitchen
+type CatalogCategory string
+
+const (
+	CatalogCategoryFood     CatalogCategory = "FOOD"
+	CatalogCategoryBeverage CatalogCategory = "BEVERAGE"
+	CatalogCategoryLaundry  CatalogCategory = "LAUNDRY"
+	CatalogCategoryMinibar  CatalogCategory = "MINIBAR"
+	CatalogCategoryService  CatalogCategory = "SERVICE"
+	CatalogCategoryOther    CatalogCategory = "OTHER"
+)
+
+// IsValid reports whether c is one of the known categories
+func (c CatalogCategory) IsValid() bool {
+	switch c {
+	case CatalogCategoryFood, CatalogCategoryBeverage, CatalogCategoryLaundry,
+		CatalogCategoryMinibar, CatalogCategoryService, CatalogCategoryOther:
+		return true
+	}
+	return false
+}
+
+// BillType returns the kind of bill the category's items are sold on
+// when they get a bill of their own: FOOD for the kitchen and bar, MANUAL
+// for everything else
+func (c CatalogCategory) BillType() BillType {
+	if c == CatalogCategoryFood || c == CatalogCategoryBeverage {
+		return BillTypeFood
+	}
+	return BillTypeManual
+}
+
+// CatalogItem is something the lodge charges for besides the room, such as
+// a dish, a laundry service or an extra bed, with its standard price and tax
+// treatment. Bill lines and folio charges that reference an item copy its
+// price, tax rate and HSN/SAC code when they are created, so later changes
+// to the catalog don't alter what was already charged. Items no longer sold
+// are deactivated.
+type CatalogItem struct {
+	ID           uuid.UUID       `gorm:"type:uuid;primaryKey" json:"id"`
+	Name         string          `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	Category     CatalogCategory `gorm:"type:varchar(20);not null;index" json:"category"`
+	DefaultPrice Money           `gorm:"not null;default:0" json:"default_price"`
+	HSNSACCode   string          `gorm:"type:varchar(10)" json:"hsn_sac_code"`
+	TaxRate      float64         `gorm:"not null;default:0" json:"tax_rate"`
+	IsActive     bool            `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+func (i *CatalogItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
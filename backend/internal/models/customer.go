@@ -12,6 +12,9 @@ type Customer struct {
 	FullName      string    `gorm:"not null" json:"full_name"`
 	Phone         string    `gorm:"not null" json:"phone"`
 	Address       string    `json:"address"`
+	StateName     string    `gorm:"type:varchar(100)" json:"state_name"`
+	StateCode     string    `gorm:"type:varchar(10)" json:"state_code"`
+	GSTNumber     string    `gorm:"type:varchar(20)" json:"gst_number"`
 	IDProofType   string    `json:"id_proof_type"`
 	IDProofNumber string    `json:"id_proof_number"`
 	CreatedAt     time.Time `json:"created_at"`
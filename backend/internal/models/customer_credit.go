@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CustomerCredit is an entry in a customer's credit ledger. Positive amounts
+// are credit held for the customer, such as the excess of an overpayment;
+// negative amounts are credit used or paid back. The customer's credit
+// balance is the sum of their entries.
+type CustomerCredit struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	CustomerID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"customer_id"`
+	Amount      Money      `gorm:"not null" json:"amount"`
+	BillID      *uuid.UUID `gorm:"type:uuid" json:"bill_id,omitempty"`
+	PaymentID   *uuid.UUID `gorm:"type:uuid" json:"payment_id,omitempty"`
+	Description string     `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (c *CustomerCredit) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
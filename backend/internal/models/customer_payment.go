@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CustomerPayment is one sum received from a customer that settles several
+// of their bills, such as a corporate guest paying for a stay's ROOM and FOOD
+// bills together. Its allocations are ordinary payments on each bill, linked
+// back to it by CustomerPaymentID, so the bills' balances and statuses move
+// as with any other payment. Whatever isn't allocated is credited to the
+// customer's account as ExcessCredited.
+type CustomerPayment struct {
+	ID              uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	CustomerID      uuid.UUID     `gorm:"type:uuid;not null;index" json:"customer_id"`
+	Customer        *Customer     `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	Amount          Money         `gorm:"not null" json:"amount"`
+	ExcessCredited  Money         `gorm:"not null;default:0" json:"excess_credited"`
+	PaymentMethod   PaymentMethod `gorm:"type:varchar(50);not null" json:"payment_method"`
+	PaymentDate     string        `gorm:"type:date;not null" json:"payment_date"`
+	ReferenceNumber string        `gorm:"type:varchar(100);index" json:"reference_number"`
+	Note            string        `gorm:"type:text" json:"note"`
+	ReceivedBy      uuid.UUID     `gorm:"type:uuid;not null" json:"received_by"`
+	CreatedAt       time.Time     `json:"created_at"`
+	Allocations     []Payment     `gorm:"foreignKey:CustomerPaymentID" json:"allocations,omitempty"`
+}
+
+func (p *CustomerPayment) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package models
+
+// DateLayout is the layout of the YYYY-MM-DD dates kept on records
+const DateLayout = "2006-01-02"
+
+// DateOnly returns the YYYY-MM-DD part of a stored date. Dates read back from
+// SQLite date columns carry a time suffix, which is dropped.
+func DateOnly(date string) string {
+	if len(date) > len(DateLayout) {
+		return date[:len(DateLayout)]
+	}
+	return date
+}
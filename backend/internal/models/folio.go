@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FolioStatus string
+
+const (
+	FolioStatusOpen   FolioStatus = "OPEN"
+	FolioStatusClosed FolioStatus = "CLOSED"
+)
+
+// Folio is the running account of a reservation while the guest is in
+// house. Restaurant, laundry, minibar and other charges are posted to it as
+// they happen and billed together at checkout, when the folio is closed.
+type Folio struct {
+	ID            uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	ReservationID uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex" json:"reservation_id"`
+	CustomerID    uuid.UUID     `gorm:"type:uuid;not null" json:"customer_id"`
+	Status        FolioStatus   `gorm:"type:varchar(20);not null;default:'OPEN'" json:"status"`
+	ClosedAt      *time.Time    `json:"closed_at,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Charges       []FolioCharge `gorm:"foreignKey:FolioID" json:"charges"`
+	// UnbilledTotal is the pre-tax amount of the charges not yet billed
+	UnbilledTotal Money `gorm:"-" json:"unbilled_total"`
+}
+
+func (f *Folio) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
+
+// AfterFind totals the unbilled charges, which are preloaded first
+func (f *Folio) AfterFind(tx *gorm.DB) error {
+	f.UnbilledTotal = 0
+	for _, c := range f.Charges {
+		if c.BillID == nil {
+			f.UnbilledTotal += c.Amount
+		}
+	}
+	return nil
+}
+
+// FolioCharge is a dated charge posted to a folio. BillType is the kind of
+// bill it goes on when the folio is split into several bills at checkout:
+// FOOD for restaurant charges, MANUAL for everything else. BillID is set once
+// the charge has been billed.
+type FolioCharge struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	FolioID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"folio_id"`
+	BillType      BillType   `gorm:"type:varchar(20);not null;default:'MANUAL'" json:"bill_type"`
+	ChargeDate    string     `gorm:"type:date;not null" json:"charge_date"`
+	Description   string     `gorm:"not null" json:"description"`
+	HSNSACCode    string     `gorm:"type:varchar(10)" json:"hsn_sac_code"`
+	Quantity      float64    `gorm:"not null;default:1" json:"quantity"`
+	UnitPrice     Money      `gorm:"not null;default:0" json:"unit_price"`
+	TaxRate       float64    `gorm:"not null;default:0" json:"tax_rate"`
+	Amount        Money      `gorm:"not null" json:"amount"`
+	CatalogItemID *uuid.UUID `gorm:"type:uuid;index" json:"catalog_item_id,omitempty"` // where the price and tax were copied from
+	BillID        *uuid.UUID `gorm:"type:uuid;index" json:"bill_id"`
+	PostedBy      uuid.UUID  `gorm:"type:uuid;not null" json:"posted_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (c *FolioCharge) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
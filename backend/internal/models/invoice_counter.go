@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type InvoiceSeries string
+
+const (
+	InvoiceSeriesGST        InvoiceSeries = "GST"
+	InvoiceSeriesNonGST     InvoiceSeries = "NON_GST"
+	InvoiceSeriesCreditNote InvoiceSeries = "CREDIT_NOTE"
+	InvoiceSeriesDebitNote  InvoiceSeries = "DEBIT_NOTE"
+)
+
+// InvoiceCounter holds the next sequence number of a number series within
+// one financial year (April to March), e.g. "2025-26"
+type InvoiceCounter struct {
+	ID            uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	Series        InvoiceSeries `gorm:"type:varchar(20);not null;uniqueIndex:idx_invoice_counters_series_fy" json:"series"`
+	FinancialYear string        `gorm:"type:varchar(9);not null;uniqueIndex:idx_invoice_counters_series_fy" json:"financial_year"`
+	NextNumber    int           `gorm:"not null;default:1" json:"next_number"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+func (ic *InvoiceCounter) BeforeCreate(tx *gorm.DB) error {
+	if ic.ID == uuid.Nil {
+		ic.ID = uuid.New()
+	}
+	return nil
+}
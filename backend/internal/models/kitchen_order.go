@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type KitchenOrderStatus string
+
+const (
+	KitchenOrderStatusOpen      KitchenOrderStatus = "OPEN"
+	KitchenOrderStatusClosed    KitchenOrderStatus = "CLOSED"
+	KitchenOrderStatusCancelled KitchenOrderStatus = "CANCELLED"
+)
+
+// KitchenOrder is a restaurant order for a table or a room. Items are added
+// as they are ordered and sent to the kitchen on numbered kitchen order
+// tickets (KOTs). Closing the order charges it to the in-house guest's
+// folio, or bills it as a FOOD bill, and records which in ReservationID or
+// BillID.
+type KitchenOrder struct {
+	ID            uuid.UUID          `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderNumber   int                `gorm:"not null;uniqueIndex" json:"order_number"`
+	TableNumber   string             `gorm:"type:varchar(20)" json:"table_number,omitempty"`
+	RoomID        *uuid.UUID         `gorm:"type:uuid" json:"room_id,omitempty"`
+	Room          *Room              `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	ReservationID *uuid.UUID         `gorm:"type:uuid;index" json:"reservation_id,omitempty"`
+	BillID        *uuid.UUID         `gorm:"type:uuid;index" json:"bill_id,omitempty"`
+	Status        KitchenOrderStatus `gorm:"type:varchar(20);not null;default:'OPEN';index" json:"status"`
+	KOTCount      int                `gorm:"not null;default:0" json:"kot_count"` // tickets sent to the kitchen so far
+	CreatedBy     uuid.UUID          `gorm:"type:uuid;not null" json:"created_by"`
+	ClosedAt      *time.Time         `json:"closed_at,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	Items         []KitchenOrderItem `gorm:"foreignKey:OrderID" json:"items"`
+}
+
+func (o *KitchenOrder) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+// KitchenOrderItem is a catalog item ordered for the kitchen, with any
+// instructions for the cook. The item's name, price, tax rate and HSN/SAC
+// code are copied from the catalog when it is ordered, and the order is
+// charged at those. KOTNumber is the ticket the item was sent on, or 0
+// while it hasn't been sent.
+type KitchenOrderItem struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID       uuid.UUID `gorm:"type:uuid;not null;index" json:"order_id"`
+	CatalogItemID uuid.UUID `gorm:"type:uuid;not null;index" json:"catalog_item_id"`
+	Description   string    `gorm:"not null" json:"description"`
+	HSNSACCode    string    `gorm:"type:varchar(10)" json:"hsn_sac_code"`
+	Quantity      float64   `gorm:"not null;default:1" json:"quantity"`
+	UnitPrice     Money     `gorm:"not null;default:0" json:"unit_price"`
+	TaxRate       float64   `gorm:"not null;default:0" json:"tax_rate"`
+	Note          string    `gorm:"type:varchar(255)" json:"note,omitempty"`
+	KOTNumber     int       `gorm:"not null;default:0" json:"kot_number"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (i *KitchenOrderItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// Sent reports whether the item has gone to the kitchen
+func (i *KitchenOrderItem) Sent() bool {
+	return i.KOTNumber != 0
+}
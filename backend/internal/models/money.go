@@ -0,0 +1,197 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in paise. Amounts are stored as integers so sums and
+// comparisons are exact; they are rounded to the nearest paisa, halves away
+// from zero, only when converted from rupees or multiplied by a rate.
+//
+// In JSON, Money is written and read as a rupee decimal such as 1234.50, so
+// API clients keep working in rupees.
+type Money int64
+
+// Rupees converts a rupee amount to Money, rounding to the nearest paisa
+func Rupees(rupees float64) Money {
+	return Money(math.Round(rupees * 100))
+}
+
+// ParseMoney parses a rupee decimal such as "1234.5" or "-0.05" exactly.
+// Digits beyond the second decimal place round the amount to the nearest
+// paisa, halves away from zero.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+		return Rupees(f), nil
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimLeft(s, "+-")
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	rupees, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	for _, c := range frac {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+
+	paddedFrac := (frac + "000")[:3]
+	paise, _ := strconv.ParseInt(paddedFrac[:2], 10, 64)
+	amount := rupees*100 + paise
+	if paddedFrac[2] >= '5' {
+		amount++
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money(amount), nil
+}
+
+// Rupees returns the amount in rupees
+func (m Money) Rupees() float64 {
+	return float64(m) / 100
+}
+
+// String formats the amount as a rupee decimal with two places, e.g. 1234.50
+func (m Money) String() string {
+	sign := ""
+	paise := int64(m)
+	if paise < 0 {
+		sign = "-"
+		paise = -paise
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, paise/100, paise%100)
+}
+
+// MulDiv returns m * num / den rounded to the nearest paisa. It is used to
+// spread an amount in proportion to other amounts.
+func (m Money) MulDiv(num, den int64) Money {
+	return Money(divRound(int64(m)*num, den))
+}
+
+// MulQuantity returns the value of quantity units priced at m, rounded to the
+// nearest paisa. Quantities are taken to three decimal places.
+func (m Money) MulQuantity(quantity float64) Money {
+	return m.MulDiv(int64(math.Round(quantity*1000)), 1000)
+}
+
+// Percent returns rate percent of m rounded to the nearest paisa. Rates are
+// taken to two decimal places, so 18 and 2.5 are both exact.
+func (m Money) Percent(rate float64) Money {
+	return m.MulDiv(int64(math.Round(rate*100)), 10000)
+}
+
+// RoundToRupee rounds the amount to a whole rupee in the given mode.
+// RoundingNone leaves it unchanged.
+func (m Money) RoundToRupee(mode RoundingMode) Money {
+	paise := int64(m)
+	whole := paise / 100 * 100
+	rest := paise - whole
+	switch mode {
+	case RoundingNone:
+		return m
+	case RoundingUp:
+		if rest > 0 {
+			whole += 100
+		}
+	case RoundingDown:
+		if rest < 0 {
+			whole -= 100
+		}
+	default:
+		whole = divRound(paise, 100) * 100
+	}
+	return Money(whole)
+}
+
+// divRound divides and rounds to the nearest integer, halves away from zero
+func divRound(n, d int64) int64 {
+	if d < 0 {
+		n, d = -n, -d
+	}
+	if n < 0 {
+		return -((-n + d/2) / d)
+	}
+	return (n + d/2) / d
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+	if s == "" {
+		*m = 0
+		return nil
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount as an integer number of paise
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
+
+// Scan reads an amount stored in paise. SQLite may hand back whole numbers
+// as floats for columns created as REAL, so floats are accepted too.
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(v)
+	case float64:
+		*m = Money(math.Round(v))
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	default:
+		return errors.New("unsupported type for Money")
+	}
+	return nil
+}
+
+func (m *Money) scanString(s string) error {
+	paise, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid stored amount %q", s)
+	}
+	*m = Money(math.Round(paise))
+	return nil
+}
+
+// GormDataType stores Money in integer columns
+func (Money) GormDataType() string {
+	return "integer"
+}
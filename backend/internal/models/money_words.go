@@ -0,0 +1,71 @@
+package models
+
+import "strings"
+
+var (
+	unitWords = []string{
+		"", "One", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine",
+		"Ten", "Eleven", "Twelve", "Thirteen", "Fourteen", "Fifteen", "Sixteen",
+		"Seventeen", "Eighteen", "Nineteen",
+	}
+	tensWords = []string{"", "", "Twenty", "Thirty", "Forty", "Fifty", "Sixty", "Seventy", "Eighty", "Ninety"}
+)
+
+// InWords spells the amount out the way it is printed on Indian invoices,
+// using lakh and crore, e.g. "Rupees Two Thousand Three Hundred Only" or
+// "Rupees One Lakh Five and Fifty Paise Only"
+func (m Money) InWords() string {
+	paise := int64(m)
+	prefix := "Rupees "
+	if paise < 0 {
+		prefix = "Minus Rupees "
+		paise = -paise
+	}
+
+	rupees, rest := paise/100, paise%100
+	words := indianNumberWords(rupees)
+	if words == "" {
+		words = "Zero"
+	}
+	if rest > 0 {
+		words += " and " + indianNumberWords(rest) + " Paise"
+	}
+	return prefix + words + " Only"
+}
+
+// indianNumberWords spells out n using the Indian grouping of crore
+// (10,000,000), lakh (100,000), thousand and hundred. Amounts of a hundred
+// crore or more are spelled as a number of crores.
+func indianNumberWords(n int64) string {
+	var parts []string
+	if crore := n / 10000000; crore > 0 {
+		parts = append(parts, indianNumberWords(crore)+" Crore")
+		n %= 10000000
+	}
+	if lakh := n / 100000; lakh > 0 {
+		parts = append(parts, belowHundredWords(lakh)+" Lakh")
+		n %= 100000
+	}
+	if thousand := n / 1000; thousand > 0 {
+		parts = append(parts, belowHundredWords(thousand)+" Thousand")
+		n %= 1000
+	}
+	if hundred := n / 100; hundred > 0 {
+		parts = append(parts, unitWords[hundred]+" Hundred")
+		n %= 100
+	}
+	if n > 0 {
+		parts = append(parts, belowHundredWords(n))
+	}
+	return strings.Join(parts, " ")
+}
+
+func belowHundredWords(n int64) string {
+	if n < 20 {
+		return unitWords[n]
+	}
+	if n%10 == 0 {
+		return tensWords[n/10]
+	}
+	return tensWords[n/10] + " " + unitWords[n%10]
+}
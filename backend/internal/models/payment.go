@@ -7,22 +7,52 @@ import (
 	"gorm.io/gorm"
 )
 
+// PaymentMethod is the name of one of the payment methods configured in
+// PaymentMethodConfig. The methods below are installed on a fresh database.
 type PaymentMethod string
 
 const (
-	PaymentMethodCash PaymentMethod = "Cash"
-	PaymentMethodCard PaymentMethod = "Card"
-	PaymentMethodUPI  PaymentMethod = "UPI"
+	PaymentMethodCash            PaymentMethod = "Cash"
+	PaymentMethodCard            PaymentMethod = "Card"
+	PaymentMethodUPI             PaymentMethod = "UPI"
+	PaymentMethodBankTransfer    PaymentMethod = "Bank Transfer"
+	PaymentMethodCheque          PaymentMethod = "Cheque"
+	PaymentMethodCorporateCredit PaymentMethod = "Corporate Credit"
+	PaymentMethodOTAPrepaid      PaymentMethod = "OTA Prepaid"
 )
 
+// Payment is money received against a bill. Amount is what was applied to
+// the bill; ExcessCredited is any overpayment put on the customer's credit
+// account instead. ReferenceNumber identifies the payment outside the lodge:
+// a UPI transaction ID, card approval code or last four digits, or a cheque
+// number. Refunds and reversals of the payment are kept as PaymentRefund
+// records rather than by changing the payment.
 type Payment struct {
-	ID            uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
-	BillID        uuid.UUID     `gorm:"type:uuid;not null" json:"bill_id"`
-	Bill          *Bill         `gorm:"foreignKey:BillID" json:"bill,omitempty"`
-	Amount        float64       `gorm:"not null" json:"amount"`
-	PaymentMethod PaymentMethod `gorm:"type:varchar(10);not null" json:"payment_method"`
-	PaymentDate   string        `gorm:"type:date;not null" json:"payment_date"`
-	CreatedAt     time.Time     `json:"created_at"`
+	ID              uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	BillID          uuid.UUID     `gorm:"type:uuid;not null" json:"bill_id"`
+	Bill            *Bill         `gorm:"foreignKey:BillID" json:"bill,omitempty"`
+	Amount          Money         `gorm:"not null" json:"amount"`
+	ExcessCredited  Money         `gorm:"not null;default:0" json:"excess_credited"`
+	PaymentMethod   PaymentMethod `gorm:"type:varchar(50);not null" json:"payment_method"`
+	PaymentDate     string        `gorm:"type:date;not null" json:"payment_date"`
+	ReferenceNumber string        `gorm:"type:varchar(100);index" json:"reference_number"`
+	Note            string        `gorm:"type:text" json:"note"`
+	DepositID       *uuid.UUID    `gorm:"type:uuid" json:"deposit_id,omitempty"` // set when an advance deposit was applied to the bill
+	// CustomerPaymentID is set when the payment is this bill's share of a
+	// sum that settled several bills
+	CustomerPaymentID *uuid.UUID      `gorm:"type:uuid;index" json:"customer_payment_id,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	Refunds           []PaymentRefund `gorm:"foreignKey:PaymentID" json:"refunds,omitempty"`
+}
+
+// Refunded returns the part of the payment already refunded or reversed.
+// Refunds must be loaded.
+func (p *Payment) Refunded() Money {
+	var total Money
+	for _, r := range p.Refunds {
+		total += r.Amount
+	}
+	return total
 }
 
 func (p *Payment) BeforeCreate(tx *gorm.DB) error {
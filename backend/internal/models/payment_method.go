@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PaymentMethodConfig is a way of paying that the front desk accepts.
+// Payments store the method's name, so a method can't be renamed once
+// created; methods no longer accepted are deactivated rather than deleted.
+type PaymentMethodConfig struct {
+	ID                uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	Name              PaymentMethod `gorm:"type:varchar(50);not null;uniqueIndex" json:"name"`
+	RequiresReference bool          `gorm:"not null;default:false" json:"requires_reference"`
+	IsActive          bool          `gorm:"not null;default:true" json:"is_active"`
+	SortOrder         int           `gorm:"not null;default:0" json:"sort_order"`
+	CreatedAt         time.Time     `json:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+}
+
+func (PaymentMethodConfig) TableName() string {
+	return "payment_methods"
+}
+
+func (m *PaymentMethodConfig) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RefundType string
+
+const (
+	// RefundTypeRefund is money paid back to the guest
+	RefundTypeRefund RefundType = "REFUND"
+	// RefundTypeReversal voids a payment that should never have been
+	// recorded, such as a duplicate or a payment that bounced
+	RefundTypeReversal RefundType = "REVERSAL"
+)
+
+// PaymentRefund takes back all or part of a payment. Refunds and reversals
+// reduce the amount paid on the bill; the original payment is left as it
+// was recorded.
+type PaymentRefund struct {
+	ID            uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	PaymentID     uuid.UUID     `gorm:"type:uuid;not null;index" json:"payment_id"`
+	BillID        uuid.UUID     `gorm:"type:uuid;not null;index" json:"bill_id"`
+	Type          RefundType    `gorm:"type:varchar(10);not null" json:"type"`
+	Amount        Money         `gorm:"not null" json:"amount"`
+	PaymentMethod PaymentMethod `gorm:"type:varchar(50);not null" json:"payment_method"`
+	RefundDate    string        `gorm:"type:date;not null" json:"refund_date"`
+	Reason        string        `gorm:"type:text;not null" json:"reason"`
+	CreatedBy     uuid.UUID     `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+func (r *PaymentRefund) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
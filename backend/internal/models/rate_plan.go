@@ -0,0 +1,93 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Weekdays are written as these three-letter names in a rate plan's
+// DaysOfWeek, e.g. "FRI,SAT"
+var Weekdays = [7]string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+// RatePlan is a nightly tariff for a room type that overrides its default
+// rate on the nights it applies to: within its dates, on its days of the
+// week, and, for plans with a Code such as a corporate rate, only when a
+// booking asks for that code. When several plans apply to a night the one
+// with the highest Priority wins.
+type RatePlan struct {
+	ID         uuid.UUID       `gorm:"type:uuid;primaryKey" json:"id"`
+	RoomTypeID uuid.UUID       `gorm:"type:uuid;not null;index" json:"room_type_id"`
+	RoomType   *RoomType       `gorm:"foreignKey:RoomTypeID" json:"room_type,omitempty"`
+	Name       string          `gorm:"type:varchar(100);not null" json:"name"`
+	Code       string          `gorm:"type:varchar(30);index" json:"code,omitempty"`
+	ValidFrom  *string         `gorm:"type:date" json:"valid_from"`
+	ValidTo    *string         `gorm:"type:date" json:"valid_to"`
+	DaysOfWeek string          `gorm:"type:varchar(30)" json:"days_of_week"` // empty for every day
+	Priority   int             `gorm:"not null;default:0" json:"priority"`
+	Prices     []RatePlanPrice `gorm:"foreignKey:RatePlanID" json:"prices"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+func (p *RatePlan) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// AppliesOn reports whether the plan covers the night starting on date,
+// ignoring its code
+func (p *RatePlan) AppliesOn(date time.Time) bool {
+	day := date.Format(DateLayout)
+	if p.ValidFrom != nil && day < DateOnly(*p.ValidFrom) {
+		return false
+	}
+	if p.ValidTo != nil && day > DateOnly(*p.ValidTo) {
+		return false
+	}
+	if p.DaysOfWeek == "" {
+		return true
+	}
+	weekday := Weekdays[date.Weekday()]
+	for _, d := range strings.Split(p.DaysOfWeek, ",") {
+		if d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// PriceFor returns the nightly rate for a number of guests: the price for
+// the smallest occupancy that holds them, or for the largest occupancy
+// listed if none does. Prices must be sorted by occupancy.
+func (p *RatePlan) PriceFor(guests int) (Money, bool) {
+	if len(p.Prices) == 0 {
+		return 0, false
+	}
+	for _, price := range p.Prices {
+		if price.Occupancy >= guests {
+			return price.Rate, true
+		}
+	}
+	return p.Prices[len(p.Prices)-1].Rate, true
+}
+
+// RatePlanPrice is a rate plan's nightly rate for a room with Occupancy
+// guests
+type RatePlanPrice struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	RatePlanID uuid.UUID `gorm:"type:uuid;not null;index" json:"rate_plan_id"`
+	Occupancy  int       `gorm:"not null" json:"occupancy"`
+	Rate       Money     `gorm:"not null" json:"rate"`
+}
+
+func (p *RatePlanPrice) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DepositLeftover says what happens to the part of a reservation's deposits
+// that its bill doesn't need
+type DepositLeftover string
+
+const (
+	DepositLeftoverCredit DepositLeftover = "CREDIT"
+	DepositLeftoverRefund DepositLeftover = "REFUND"
+)
+
+// ReservationDeposit is an advance paid against a reservation before it has
+// a bill. When the reservation's room bill is created, its deposits are
+// applied to the bill as payments and the deposit is settled: AppliedAmount
+// went to the bill, and anything left over was either refunded or credited
+// to the guest's account.
+type ReservationDeposit struct {
+	ID              uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	ReservationID   uuid.UUID     `gorm:"type:uuid;not null;index" json:"reservation_id"`
+	CustomerID      uuid.UUID     `gorm:"type:uuid;not null" json:"customer_id"`
+	Amount          Money         `gorm:"not null" json:"amount"`
+	PaymentMethod   PaymentMethod `gorm:"type:varchar(50);not null" json:"payment_method"`
+	ReferenceNumber string        `gorm:"type:varchar(100);index" json:"reference_number"`
+	Note            string        `gorm:"type:text" json:"note"`
+	DepositDate     string        `gorm:"type:date;not null" json:"deposit_date"`
+	ReceivedBy      uuid.UUID     `gorm:"type:uuid;not null" json:"received_by"`
+	BillID          *uuid.UUID    `gorm:"type:uuid" json:"bill_id"`
+	AppliedAmount   Money         `gorm:"not null;default:0" json:"applied_amount"`
+	RefundedAmount  Money         `gorm:"not null;default:0" json:"refunded_amount"`
+	CreditedAmount  Money         `gorm:"not null;default:0" json:"credited_amount"`
+	SettledDate     *string       `gorm:"type:date" json:"settled_date"`
+	CreatedAt       time.Time     `json:"created_at"`
+}
+
+func (d *ReservationDeposit) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsSettled reports whether the deposit has been applied to a bill
+func (d *ReservationDeposit) IsSettled() bool {
+	return d.SettledDate != nil
+}
@@ -18,7 +18,7 @@ const (
 type RoomType struct {
 	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	Name        string    `gorm:"not null" json:"name"`
-	DefaultRate float64   `gorm:"not null" json:"default_rate"`
+	DefaultRate Money     `gorm:"not null" json:"default_rate"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
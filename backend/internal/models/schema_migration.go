@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// SchemaMigration records a data migration that has been applied, so each
+// one runs exactly once per database
+type SchemaMigration struct {
+	Version   string    `gorm:"type:varchar(100);primaryKey" json:"version"`
+	AppliedAt time.Time `json:"applied_at"`
+}
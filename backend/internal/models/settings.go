@@ -7,20 +7,44 @@ import (
 	"gorm.io/gorm"
 )
 
+// RoundingMode controls how a bill's grand total is rounded to a whole rupee
+type RoundingMode string
+
+const (
+	RoundingNearest RoundingMode = "NEAREST"
+	RoundingUp      RoundingMode = "UP"
+	RoundingDown    RoundingMode = "DOWN"
+	RoundingNone    RoundingMode = "NONE"
+)
+
 type Settings struct {
-	ID                    uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	LodgeName             string    `gorm:"type:varchar(255);not null" json:"lodge_name"`
-	Address               string    `gorm:"type:text" json:"address"`
-	Phone                 string    `gorm:"type:varchar(20)" json:"phone"`
-	GSTNumber             string    `gorm:"type:varchar(20)" json:"gst_number"`
-	StateName             string    `gorm:"type:varchar(100)" json:"state_name"`
-	StateCode             string    `gorm:"type:varchar(10)" json:"state_code"`
-	GSTInvoicePrefix      string    `gorm:"type:varchar(20);default:'GST'" json:"gst_invoice_prefix"`
-	GSTInvoiceNextNumber  int       `gorm:"default:1" json:"gst_invoice_next_number"`
-	NonGSTInvoicePrefix   string    `gorm:"type:varchar(20);default:'INV'" json:"non_gst_invoice_prefix"`
-	NonGSTInvoiceNextNumber int     `gorm:"default:1" json:"non_gst_invoice_next_number"`
-	CreatedAt             time.Time `json:"created_at"`
-	UpdatedAt             time.Time `json:"updated_at"`
+	ID                      uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	LodgeName               string       `gorm:"type:varchar(255);not null" json:"lodge_name"`
+	Address                 string       `gorm:"type:text" json:"address"`
+	Phone                   string       `gorm:"type:varchar(20)" json:"phone"`
+	GSTNumber               string       `gorm:"type:varchar(20)" json:"gst_number"`
+	StateName               string       `gorm:"type:varchar(100)" json:"state_name"`
+	StateCode               string       `gorm:"type:varchar(10)" json:"state_code"`
+	GSTInvoicePrefix        string       `gorm:"type:varchar(20);default:'GST'" json:"gst_invoice_prefix"`
+	GSTInvoiceFormat        string       `gorm:"type:varchar(50);default:'{PREFIX}/{FY}/{SEQ:4}'" json:"gst_invoice_format"`
+	GSTInvoiceNextNumber    int          `gorm:"default:1" json:"gst_invoice_next_number"`
+	NonGSTInvoicePrefix     string       `gorm:"type:varchar(20);default:'INV'" json:"non_gst_invoice_prefix"`
+	NonGSTInvoiceFormat     string       `gorm:"type:varchar(50);default:'{PREFIX}/{FY}/{SEQ:4}'" json:"non_gst_invoice_format"`
+	NonGSTInvoiceNextNumber int          `gorm:"default:1" json:"non_gst_invoice_next_number"`
+	CreditNotePrefix        string       `gorm:"type:varchar(20);default:'CN'" json:"credit_note_prefix"`
+	CreditNoteFormat        string       `gorm:"type:varchar(50);default:'{PREFIX}/{FY}/{SEQ:4}'" json:"credit_note_format"`
+	CreditNoteNextNumber    int          `gorm:"default:1" json:"credit_note_next_number"`
+	DebitNotePrefix         string       `gorm:"type:varchar(20);default:'DN'" json:"debit_note_prefix"`
+	DebitNoteFormat         string       `gorm:"type:varchar(50);default:'{PREFIX}/{FY}/{SEQ:4}'" json:"debit_note_format"`
+	DebitNoteNextNumber     int          `gorm:"default:1" json:"debit_note_next_number"`
+	ReceiptPrinterAddress   string       `gorm:"type:varchar(100)" json:"receipt_printer_address"`
+	ReceiptPaperWidth       int          `gorm:"default:80" json:"receipt_paper_width"`
+	KitchenPrinterAddress   string       `gorm:"type:varchar(100)" json:"kitchen_printer_address"`
+	RoundingMode            RoundingMode `gorm:"type:varchar(10);default:'NEAREST'" json:"rounding_mode"`
+	UPIVPA                  string       `gorm:"type:varchar(100)" json:"upi_vpa"`
+	UPIPayeeName            string       `gorm:"type:varchar(255)" json:"upi_payee_name"`
+	CreatedAt               time.Time    `json:"created_at"`
+	UpdatedAt               time.Time    `json:"updated_at"`
 }
 
 func (s *Settings) BeforeCreate(tx *gorm.DB) error {
@@ -28,4 +52,29 @@ func (s *Settings) BeforeCreate(tx *gorm.DB) error {
 		s.ID = uuid.New()
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// Series returns the prefix and number format configured for a series, and
+// a pointer to its next-number field. The next numbers shown in settings are
+// those of the current financial year; the counters themselves live in
+// InvoiceCounter.
+func (s *Settings) Series(series InvoiceSeries) (prefix, format string, nextNumber *int) {
+	switch series {
+	case InvoiceSeriesNonGST:
+		return s.NonGSTInvoicePrefix, s.NonGSTInvoiceFormat, &s.NonGSTInvoiceNextNumber
+	case InvoiceSeriesCreditNote:
+		return s.CreditNotePrefix, s.CreditNoteFormat, &s.CreditNoteNextNumber
+	case InvoiceSeriesDebitNote:
+		return s.DebitNotePrefix, s.DebitNoteFormat, &s.DebitNoteNextNumber
+	default:
+		return s.GSTInvoicePrefix, s.GSTInvoiceFormat, &s.GSTInvoiceNextNumber
+	}
+}
+
+// AllInvoiceSeries lists every number series kept in settings
+var AllInvoiceSeries = []InvoiceSeries{
+	InvoiceSeriesGST,
+	InvoiceSeriesNonGST,
+	InvoiceSeriesCreditNote,
+	InvoiceSeriesDebitNote,
+}
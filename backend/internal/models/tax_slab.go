@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TaxSlab maps a range of per-unit values (for rooms, the nightly tariff) to
+// a GST rate. Slabs are dated so that rate changes can be recorded ahead of
+// time without affecting bills dated before the change.
+type TaxSlab struct {
+	ID            uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	ItemType      LineItemType `gorm:"type:varchar(20);not null;index" json:"item_type"`
+	MinAmount     Money        `gorm:"not null;default:0" json:"min_amount"`
+	MaxAmount     *Money       `json:"max_amount"`
+	Rate          float64      `gorm:"not null;default:0" json:"rate"`
+	EffectiveFrom string       `gorm:"type:date;not null" json:"effective_from"`
+	EffectiveTo   *string      `gorm:"type:date" json:"effective_to"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+func (ts *TaxSlab) BeforeCreate(tx *gorm.DB) error {
+	if ts.ID == uuid.Nil {
+		ts.ID = uuid.New()
+	}
+	return nil
+}
+
+// Covers reports whether the slab applies to the given per-unit amount
+func (ts *TaxSlab) Covers(amount Money) bool {
+	if amount < ts.MinAmount {
+		return false
+	}
+	return ts.MaxAmount == nil || amount <= *ts.MaxAmount
+}
@@ -1,12 +1,18 @@
 package repository
 
 import (
+	"errors"
+	"time"
 	"trinity-lodge/internal/models"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrBillLocked is returned when saving a bill that is no longer a draft
+var ErrBillLocked = errors.New("finalized bills cannot be modified; issue a credit or debit note instead")
+
 type BillRepository struct {
 	db *gorm.DB
 }
@@ -15,6 +21,17 @@ func NewBillRepository(db *gorm.DB) *BillRepository {
 	return &BillRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *BillRepository) WithTx(tx *gorm.DB) *BillRepository {
+	return &BillRepository{db: tx}
+}
+
+// Transaction runs fn in a database transaction, rolling back everything it
+// wrote if fn returns an error
+func (r *BillRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
 func (r *BillRepository) Create(bill *models.Bill) error {
 	return r.db.Create(bill).Error
 }
@@ -24,6 +41,9 @@ func (r *BillRepository) FindByID(id uuid.UUID) (*models.Bill, error) {
 	err := r.db.Preload("Customer").
 		Preload("Reservation.Room.Type").
 		Preload("LineItems").
+		Preload("Notes", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at")
+		}).
 		First(&bill, "id = ?", id).Error
 	if err != nil {
 		return nil, err
@@ -41,6 +61,14 @@ func (r *BillRepository) FindByCustomerID(customerID uuid.UUID) ([]models.Bill,
 	return bills, err
 }
 
+func (r *BillRepository) FindByReservationID(reservationID uuid.UUID) ([]models.Bill, error) {
+	var bills []models.Bill
+	err := r.db.Where("reservation_id = ?", reservationID).
+		Order("created_at DESC").
+		Find(&bills).Error
+	return bills, err
+}
+
 func (r *BillRepository) FindAll() ([]models.Bill, error) {
 	var bills []models.Bill
 	err := r.db.Preload("Customer").
@@ -50,14 +78,70 @@ func (r *BillRepository) FindAll() ([]models.Bill, error) {
 	return bills, err
 }
 
+// Update saves a draft bill. Bills that have been finalized are left
+// untouched and ErrBillLocked is returned.
 func (r *BillRepository) Update(bill *models.Bill) error {
-	return r.db.Save(bill).Error
+	result := r.db.Model(&models.Bill{}).
+		Where("id = ? AND status = ?", bill.ID, models.BillStatusDraft).
+		Select("*").
+		Omit("id", "created_at", "invoice_number", clause.Associations).
+		Updates(bill)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBillLocked
+	}
+	return nil
 }
 
 func (r *BillRepository) UpdateStatus(id uuid.UUID, status models.BillStatus) error {
 	return r.db.Model(&models.Bill{}).Where("id = ?", id).Update("status", status).Error
 }
 
+// CreditedTotal returns the sum of the credit notes issued against an
+// invoice that haven't been cancelled
+func (r *BillRepository) CreditedTotal(id uuid.UUID) (models.Money, error) {
+	var total models.Money
+	err := r.db.Model(&models.Bill{}).
+		Select("COALESCE(SUM(total_amount), 0)").
+		Where("original_bill_id = ? AND document_type = ? AND status <> ?",
+			id, models.DocumentTypeCreditNote, models.BillStatusCancelled).
+		Scan(&total).Error
+	return total, err
+}
+
+// UpdateSettlement saves a bill's amount paid, balance due and status
+func (r *BillRepository) UpdateSettlement(bill *models.Bill) error {
+	return r.db.Model(&models.Bill{}).Where("id = ?", bill.ID).
+		Updates(map[string]interface{}{
+			"amount_paid": bill.AmountPaid,
+			"balance_due": bill.BalanceDue,
+			"status":      bill.Status,
+		}).Error
+}
+
+// Cancel marks a bill as cancelled, keeping its invoice number reserved
+func (r *BillRepository) Cancel(id uuid.UUID, reason string, cancelledBy uuid.UUID, cancelledAt time.Time) error {
+	return r.db.Model(&models.Bill{}).
+		Where("id = ? AND status <> ?", id, models.BillStatusCancelled).
+		Updates(map[string]interface{}{
+			"status":        models.BillStatusCancelled,
+			"cancel_reason": reason,
+			"cancelled_by":  cancelledBy,
+			"cancelled_at":  cancelledAt,
+		}).Error
+}
+
+// FindIssuedBetween returns the non-draft bills dated within the given range
+func (r *BillRepository) FindIssuedBetween(from, to string) ([]models.Bill, error) {
+	var bills []models.Bill
+	err := r.db.Where("bill_date BETWEEN ? AND ? AND status <> ?", from, to, models.BillStatusDraft).
+		Order("bill_date, invoice_number").
+		Find(&bills).Error
+	return bills, err
+}
+
 // Line Items
 func (r *BillRepository) CreateLineItem(lineItem *models.BillLineItem) error {
 	return r.db.Create(lineItem).Error
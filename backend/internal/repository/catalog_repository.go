@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"strings"
+	"trinity-lodge/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CatalogRepository struct {
+	db *gorm.DB
+}
+
+func NewCatalogRepository(db *gorm.DB) *CatalogRepository {
+	return &CatalogRepository{db: db}
+}
+
+func (r *CatalogRepository) Create(item *models.CatalogItem) error {
+	return r.db.Create(item).Error
+}
+
+// CatalogFilter narrows a catalog listing. Empty fields are ignored.
+type CatalogFilter struct {
+	Category        models.CatalogCategory
+	IncludeInactive bool
+}
+
+// FindAll returns the catalog items matching filter by category and name
+func (r *CatalogRepository) FindAll(filter CatalogFilter) ([]models.CatalogItem, error) {
+	query := r.db.Order("category, name")
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if !filter.IncludeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var items []models.CatalogItem
+	err := query.Find(&items).Error
+	return items, err
+}
+
+func (r *CatalogRepository) FindByID(id uuid.UUID) (*models.CatalogItem, error) {
+	var item models.CatalogItem
+	err := r.db.First(&item, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// FindByName looks an item up by name, ignoring case and surrounding spaces
+func (r *CatalogRepository) FindByName(name string) (*models.CatalogItem, error) {
+	var item models.CatalogItem
+	err := r.db.First(&item, "LOWER(name) = ?", strings.ToLower(strings.TrimSpace(name))).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *CatalogRepository) Update(item *models.CatalogItem) error {
+	return r.db.Save(item).Error
+}
+
+func (r *CatalogRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.CatalogItem{}, "id = ?", id).Error
+}
+
+// IsReferenced reports whether any bill line, folio charge or kitchen order
+// item was made from the item
+func (r *CatalogRepository) IsReferenced(id uuid.UUID) (bool, error) {
+	var count int64
+	for _, model := range []interface{}{&models.BillLineItem{}, &models.FolioCharge{}, &models.KitchenOrderItem{}} {
+		if err := r.db.Model(model).Where("catalog_item_id = ?", id).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"trinity-lodge/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CustomerCreditRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomerCreditRepository(db *gorm.DB) *CustomerCreditRepository {
+	return &CustomerCreditRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *CustomerCreditRepository) WithTx(tx *gorm.DB) *CustomerCreditRepository {
+	return &CustomerCreditRepository{db: tx}
+}
+
+func (r *CustomerCreditRepository) Create(credit *models.CustomerCredit) error {
+	return r.db.Create(credit).Error
+}
+
+// FindByCustomerID returns a customer's ledger entries, newest first
+func (r *CustomerCreditRepository) FindByCustomerID(customerID uuid.UUID) ([]models.CustomerCredit, error) {
+	var credits []models.CustomerCredit
+	err := r.db.Where("customer_id = ?", customerID).Order("created_at DESC").Find(&credits).Error
+	return credits, err
+}
+
+// Balance returns the credit a customer has available
+func (r *CustomerCreditRepository) Balance(customerID uuid.UUID) (models.Money, error) {
+	var balance models.Money
+	err := r.db.Model(&models.CustomerCredit{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("customer_id = ?", customerID).
+		Scan(&balance).Error
+	return balance, err
+}
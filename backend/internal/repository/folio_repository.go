@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"trinity-lodge/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrChargesBilled is returned when marking charges that are already on a
+// bill as billed
+var ErrChargesBilled = errors.New("folio charges have already been billed")
+
+type FolioRepository struct {
+	db *gorm.DB
+}
+
+func NewFolioRepository(db *gorm.DB) *FolioRepository {
+	return &FolioRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *FolioRepository) WithTx(tx *gorm.DB) *FolioRepository {
+	return &FolioRepository{db: tx}
+}
+
+// Transaction runs fn in a database transaction
+func (r *FolioRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *FolioRepository) Create(folio *models.Folio) error {
+	return r.db.Omit("Charges").Create(folio).Error
+}
+
+// FindByReservationID returns a reservation's folio with its charges in the
+// order they were incurred
+func (r *FolioRepository) FindByReservationID(reservationID uuid.UUID) (*models.Folio, error) {
+	var folio models.Folio
+	err := r.db.Preload("Charges", func(db *gorm.DB) *gorm.DB {
+		return db.Order("charge_date, created_at")
+	}).First(&folio, "reservation_id = ?", reservationID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &folio, nil
+}
+
+// SetStatus opens or closes a folio
+func (r *FolioRepository) SetStatus(id uuid.UUID, status models.FolioStatus) error {
+	var closedAt *time.Time
+	if status == models.FolioStatusClosed {
+		now := time.Now()
+		closedAt = &now
+	}
+	return r.db.Model(&models.Folio{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":    status,
+		"closed_at": closedAt,
+	}).Error
+}
+
+// Charges
+func (r *FolioRepository) CreateCharge(charge *models.FolioCharge) error {
+	return r.db.Create(charge).Error
+}
+
+func (r *FolioRepository) DeleteCharge(id uuid.UUID) error {
+	return r.db.Delete(&models.FolioCharge{}, "id = ?", id).Error
+}
+
+// MarkChargesBilled records the bill that the given charges went on. None of
+// the charges may already be on a bill.
+func (r *FolioRepository) MarkChargesBilled(ids []uuid.UUID, billID uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	result := r.db.Model(&models.FolioCharge{}).
+		Where("id IN ? AND bill_id IS NULL", ids).
+		Update("bill_id", billID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != int64(len(ids)) {
+		return ErrChargesBilled
+	}
+	return nil
+}
+
+// ReleaseCharges makes the charges billed on a bill unbilled again and
+// reopens their folio, for when the bill is cancelled
+func (r *FolioRepository) ReleaseCharges(billID uuid.UUID) error {
+	var folioIDs []uuid.UUID
+	err := r.db.Model(&models.FolioCharge{}).
+		Distinct("folio_id").
+		Where("bill_id = ?", billID).
+		Pluck("folio_id", &folioIDs).Error
+	if err != nil || len(folioIDs) == 0 {
+		return err
+	}
+	err = r.db.Model(&models.FolioCharge{}).Where("bill_id = ?", billID).Update("bill_id", nil).Error
+	if err != nil {
+		return err
+	}
+	return r.db.Model(&models.Folio{}).Where("id IN ?", folioIDs).Updates(map[string]interface{}{
+		"status":    models.FolioStatusOpen,
+		"closed_at": nil,
+	}).Error
+}
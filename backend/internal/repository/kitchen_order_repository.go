@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"time"
+	"trinity-lodge/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type KitchenOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewKitchenOrderRepository(db *gorm.DB) *KitchenOrderRepository {
+	return &KitchenOrderRepository{db: db}
+}
+
+// Create numbers the order one past the last order and stores it. The
+// number is read and used in one transaction so two orders can't share it.
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *KitchenOrderRepository) WithTx(tx *gorm.DB) *KitchenOrderRepository {
+	return &KitchenOrderRepository{db: tx}
+}
+
+// Transaction runs fn in a database transaction
+func (r *KitchenOrderRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *KitchenOrderRepository) Create(order *models.KitchenOrder) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var last int
+		err := tx.Model(&models.KitchenOrder{}).Select("COALESCE(MAX(order_number), 0)").Scan(&last).Error
+		if err != nil {
+			return err
+		}
+		order.OrderNumber = last + 1
+		return tx.Omit("Items", "Room").Create(order).Error
+	})
+}
+
+// FindAll lists orders newest first, optionally only those with the given
+// status
+func (r *KitchenOrderRepository) FindAll(status models.KitchenOrderStatus) ([]models.KitchenOrder, error) {
+	var orders []models.KitchenOrder
+	query := r.db.Preload("Room").Preload("Items", orderItems)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("order_number DESC").Find(&orders).Error
+	return orders, err
+}
+
+// FindByID returns an order with its room and items in the order they were
+// added
+func (r *KitchenOrderRepository) FindByID(id uuid.UUID) (*models.KitchenOrder, error) {
+	var order models.KitchenOrder
+	err := r.db.Preload("Room").Preload("Items", orderItems).First(&order, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func orderItems(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at")
+}
+
+// ChangeStatus moves an order from one status to another, reporting false
+// if it was no longer in the from status. Closing or cancelling an order
+// records when it happened; reopening it clears that.
+func (r *KitchenOrderRepository) ChangeStatus(id uuid.UUID, from, to models.KitchenOrderStatus) (bool, error) {
+	var closedAt *time.Time
+	if to != models.KitchenOrderStatusOpen {
+		now := time.Now()
+		closedAt = &now
+	}
+	result := r.db.Model(&models.KitchenOrder{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]interface{}{"status": to, "closed_at": closedAt})
+	return result.RowsAffected == 1, result.Error
+}
+
+// SetSettlement records where a closed order was charged: the reservation
+// whose folio it was posted to, or the bill it was billed on
+func (r *KitchenOrderRepository) SetSettlement(id uuid.UUID, reservationID, billID *uuid.UUID) error {
+	return r.db.Model(&models.KitchenOrder{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"reservation_id": reservationID,
+		"bill_id":        billID,
+	}).Error
+}
+
+// Items
+func (r *KitchenOrderRepository) CreateItem(item *models.KitchenOrderItem) error {
+	return r.db.Create(item).Error
+}
+
+func (r *KitchenOrderRepository) DeleteItem(id uuid.UUID) error {
+	return r.db.Delete(&models.KitchenOrderItem{}, "id = ?", id).Error
+}
+
+// MarkItemsSent records that the given items went to the kitchen on the
+// order's next ticket, kotNumber
+func (r *KitchenOrderRepository) MarkItemsSent(orderID uuid.UUID, itemIDs []uuid.UUID, kotNumber int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.KitchenOrderItem{}).
+			Where("order_id = ? AND id IN ?", orderID, itemIDs).
+			Update("kot_number", kotNumber).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.KitchenOrder{}).Where("id = ?", orderID).Update("kot_count", kotNumber).Error
+	})
+}
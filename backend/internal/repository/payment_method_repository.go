@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"strings"
+	"trinity-lodge/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PaymentMethodRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentMethodRepository(db *gorm.DB) *PaymentMethodRepository {
+	return &PaymentMethodRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *PaymentMethodRepository) WithTx(tx *gorm.DB) *PaymentMethodRepository {
+	return &PaymentMethodRepository{db: tx}
+}
+
+func (r *PaymentMethodRepository) Create(method *models.PaymentMethodConfig) error {
+	return r.db.Create(method).Error
+}
+
+// FindAll returns the payment methods in display order, leaving out
+// inactive ones unless includeInactive is set
+func (r *PaymentMethodRepository) FindAll(includeInactive bool) ([]models.PaymentMethodConfig, error) {
+	var methods []models.PaymentMethodConfig
+	query := r.db.Order("sort_order, name")
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Find(&methods).Error
+	return methods, err
+}
+
+func (r *PaymentMethodRepository) FindByID(id uuid.UUID) (*models.PaymentMethodConfig, error) {
+	var method models.PaymentMethodConfig
+	err := r.db.First(&method, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+// FindByName looks a payment method up by name, ignoring case and
+// surrounding spaces
+func (r *PaymentMethodRepository) FindByName(name string) (*models.PaymentMethodConfig, error) {
+	var method models.PaymentMethodConfig
+	err := r.db.First(&method, "LOWER(name) = ?", strings.ToLower(strings.TrimSpace(name))).Error
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+func (r *PaymentMethodRepository) Update(method *models.PaymentMethodConfig) error {
+	return r.db.Save(method).Error
+}
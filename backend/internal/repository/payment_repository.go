@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"strings"
 	"trinity-lodge/internal/models"
 
 	"github.com/google/uuid"
@@ -15,21 +16,141 @@ func NewPaymentRepository(db *gorm.DB) *PaymentRepository {
 	return &PaymentRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *PaymentRepository) WithTx(tx *gorm.DB) *PaymentRepository {
+	return &PaymentRepository{db: tx}
+}
+
 func (r *PaymentRepository) Create(payment *models.Payment) error {
 	return r.db.Create(payment).Error
 }
 
 func (r *PaymentRepository) FindByBillID(billID uuid.UUID) ([]models.Payment, error) {
 	var payments []models.Payment
-	err := r.db.Where("bill_id = ?", billID).Order("created_at DESC").Find(&payments).Error
+	err := r.db.Preload("Refunds").Where("bill_id = ?", billID).Order("created_at DESC").Find(&payments).Error
+	return payments, err
+}
+
+// PaymentFilter narrows a payment search. Empty fields are ignored.
+type PaymentFilter struct {
+	Reference string // any part of the reference number, in any case
+	Method    models.PaymentMethod
+	From      string
+	To        string
+}
+
+// Search returns the payments matching filter with their bills and
+// customers, newest first
+func (r *PaymentRepository) Search(filter PaymentFilter) ([]models.Payment, error) {
+	query := r.db.Preload("Refunds").Preload("Bill.Customer")
+	if filter.Reference != "" {
+		query = query.Where("LOWER(reference_number) LIKE ?", "%"+strings.ToLower(filter.Reference)+"%")
+	}
+	if filter.Method != "" {
+		query = query.Where("LOWER(payment_method) = ?", strings.ToLower(string(filter.Method)))
+	}
+	if filter.From != "" {
+		query = query.Where("payment_date >= ?", filter.From)
+	}
+	if filter.To != "" {
+		query = query.Where("payment_date <= ?", filter.To)
+	}
+
+	var payments []models.Payment
+	err := query.Order("payment_date DESC, created_at DESC").Find(&payments).Error
+	return payments, err
+}
+
+// FindBetween returns the payments dated within the given range
+func (r *PaymentRepository) FindBetween(from, to string) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.Where("payment_date BETWEEN ? AND ?", from, to).
+		Order("payment_date, created_at").
+		Find(&payments).Error
 	return payments, err
 }
 
+// TotalForBill returns what has been paid on a bill, net of refunds and
+// reversals
+func (r *PaymentRepository) TotalForBill(billID uuid.UUID) (models.Money, error) {
+	var paid, refunded models.Money
+	err := r.db.Model(&models.Payment{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("bill_id = ?", billID).
+		Scan(&paid).Error
+	if err != nil {
+		return 0, err
+	}
+	err = r.db.Model(&models.PaymentRefund{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("bill_id = ?", billID).
+		Scan(&refunded).Error
+	return paid - refunded, err
+}
+
 func (r *PaymentRepository) FindByID(id uuid.UUID) (*models.Payment, error) {
 	var payment models.Payment
-	err := r.db.First(&payment, "id = ?", id).Error
+	err := r.db.Preload("Refunds").First(&payment, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// Customer payments
+func (r *PaymentRepository) CreateCustomerPayment(payment *models.CustomerPayment) error {
+	return r.db.Omit("Allocations").Create(payment).Error
+}
+
+// FindCustomerPayment returns a customer payment with its customer and its
+// allocations to bills
+func (r *PaymentRepository) FindCustomerPayment(id uuid.UUID) (*models.CustomerPayment, error) {
+	var payment models.CustomerPayment
+	err := r.db.Preload("Customer").
+		Preload("Allocations", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at")
+		}).
+		Preload("Allocations.Bill").
+		Preload("Allocations.Refunds").
+		First(&payment, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &payment, nil
 }
+
+func (r *PaymentRepository) FindCustomerPaymentsByCustomerID(customerID uuid.UUID) ([]models.CustomerPayment, error) {
+	var payments []models.CustomerPayment
+	err := r.db.Preload("Allocations", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at")
+	}).
+		Where("customer_id = ?", customerID).
+		Order("payment_date DESC, created_at DESC").
+		Find(&payments).Error
+	return payments, err
+}
+
+// FindCustomerPaymentsBetween returns the customer payments dated within the
+// given range
+func (r *PaymentRepository) FindCustomerPaymentsBetween(from, to string) ([]models.CustomerPayment, error) {
+	var payments []models.CustomerPayment
+	err := r.db.Where("payment_date BETWEEN ? AND ?", from, to).
+		Order("payment_date, created_at").
+		Find(&payments).Error
+	return payments, err
+}
+
+// Refunds
+func (r *PaymentRepository) CreateRefund(refund *models.PaymentRefund) error {
+	return r.db.Create(refund).Error
+}
+
+// FindRefundsBetween returns the refunds and reversals dated within the
+// given range
+func (r *PaymentRepository) FindRefundsBetween(from, to string) ([]models.PaymentRefund, error) {
+	var refunds []models.PaymentRefund
+	err := r.db.Where("refund_date BETWEEN ? AND ?", from, to).
+		Order("refund_date, created_at").
+		Find(&refunds).Error
+	return refunds, err
+}
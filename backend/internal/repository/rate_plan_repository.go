@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"trinity-lodge/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RatePlanRepository struct {
+	db *gorm.DB
+}
+
+func NewRatePlanRepository(db *gorm.DB) *RatePlanRepository {
+	return &RatePlanRepository{db: db}
+}
+
+func (r *RatePlanRepository) Create(plan *models.RatePlan) error {
+	return r.db.Omit("RoomType").Create(plan).Error
+}
+
+// FindAll lists rate plans by room type and priority, optionally for one
+// room type only
+func (r *RatePlanRepository) FindAll(roomTypeID *uuid.UUID) ([]models.RatePlan, error) {
+	var plans []models.RatePlan
+	query := r.db.Preload("RoomType").Preload("Prices", pricesByOccupancy)
+	if roomTypeID != nil {
+		query = query.Where("room_type_id = ?", *roomTypeID)
+	}
+	err := query.Order("room_type_id, priority DESC, name").Find(&plans).Error
+	return plans, err
+}
+
+func (r *RatePlanRepository) FindByID(id uuid.UUID) (*models.RatePlan, error) {
+	var plan models.RatePlan
+	err := r.db.Preload("RoomType").Preload("Prices", pricesByOccupancy).First(&plan, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// FindForRoomType returns the plans of a room type, highest priority first,
+// with their prices in occupancy order
+func (r *RatePlanRepository) FindForRoomType(roomTypeID uuid.UUID) ([]models.RatePlan, error) {
+	var plans []models.RatePlan
+	err := r.db.Preload("Prices", pricesByOccupancy).
+		Where("room_type_id = ?", roomTypeID).
+		Order("priority DESC, created_at DESC").
+		Find(&plans).Error
+	return plans, err
+}
+
+func pricesByOccupancy(db *gorm.DB) *gorm.DB {
+	return db.Order("occupancy")
+}
+
+// Update saves a plan and replaces its prices with plan.Prices
+func (r *RatePlanRepository) Update(plan *models.RatePlan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("RoomType", "Prices").Save(plan).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.RatePlanPrice{}, "rate_plan_id = ?", plan.ID).Error; err != nil {
+			return err
+		}
+		for i := range plan.Prices {
+			plan.Prices[i].ID = uuid.Nil
+			plan.Prices[i].RatePlanID = plan.ID
+		}
+		if len(plan.Prices) == 0 {
+			return nil
+		}
+		return tx.Create(&plan.Prices).Error
+	})
+}
+
+func (r *RatePlanRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.RatePlanPrice{}, "rate_plan_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.RatePlan{}, "id = ?", id).Error
+	})
+}
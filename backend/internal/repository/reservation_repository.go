@@ -15,6 +15,16 @@ func NewReservationRepository(db *gorm.DB) *ReservationRepository {
 	return &ReservationRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *ReservationRepository) WithTx(tx *gorm.DB) *ReservationRepository {
+	return &ReservationRepository{db: tx}
+}
+
+// Transaction runs fn in a database transaction
+func (r *ReservationRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
 func (r *ReservationRepository) Create(reservation *models.Reservation) error {
 	return r.db.Create(reservation).Error
 }
@@ -27,7 +37,12 @@ func (r *ReservationRepository) FindAll() ([]models.Reservation, error) {
 
 func (r *ReservationRepository) FindByID(id uuid.UUID) (*models.Reservation, error) {
 	var reservation models.Reservation
-	err := r.db.Preload("Customer").Preload("Room.Type").First(&reservation, "id = ?", id).Error
+	err := r.db.Preload("Customer").
+		Preload("Room.Type").
+		Preload("Deposits", func(db *gorm.DB) *gorm.DB {
+			return db.Order("deposit_date, created_at")
+		}).
+		First(&reservation, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -56,3 +71,82 @@ func (r *ReservationRepository) FindOverlappingReservations(roomID uuid.UUID, ch
 		roomID, models.ReservationStatusActive, checkOutDate, checkInDate).Find(&reservations).Error
 	return reservations, err
 }
+
+// FindBookedRoomIDs returns the rooms with an active reservation overlapping
+// the stay from checkInDate to checkOutDate
+func (r *ReservationRepository) FindBookedRoomIDs(checkInDate, checkOutDate string) ([]uuid.UUID, error) {
+	var roomIDs []uuid.UUID
+	err := r.db.Model(&models.Reservation{}).
+		Where("status = ? AND check_in_date < ? AND expected_check_out_date > ?",
+			models.ReservationStatusActive, checkOutDate, checkInDate).
+		Distinct().Pluck("room_id", &roomIDs).Error
+	return roomIDs, err
+}
+
+// FindInHouseByRoomID returns the checked-in reservation of a room, if
+// any
+func (r *ReservationRepository) FindInHouseByRoomID(roomID uuid.UUID) (*models.Reservation, error) {
+	var reservation models.Reservation
+	err := r.db.Where("room_id = ? AND status = ? AND actual_check_in_date IS NOT NULL", roomID, models.ReservationStatusActive).
+		Order("actual_check_in_date DESC").
+		First(&reservation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Deposits
+func (r *ReservationRepository) CreateDeposit(deposit *models.ReservationDeposit) error {
+	return r.db.Create(deposit).Error
+}
+
+// FindDeposits returns a reservation's deposits in the order they were paid
+func (r *ReservationRepository) FindDeposits(reservationID uuid.UUID) ([]models.ReservationDeposit, error) {
+	var deposits []models.ReservationDeposit
+	err := r.db.Where("reservation_id = ?", reservationID).
+		Order("deposit_date, created_at").
+		Find(&deposits).Error
+	return deposits, err
+}
+
+// FindHeldDeposits returns the deposits on a reservation not yet applied to a bill
+func (r *ReservationRepository) FindHeldDeposits(reservationID uuid.UUID) ([]models.ReservationDeposit, error) {
+	var deposits []models.ReservationDeposit
+	err := r.db.Where("reservation_id = ? AND settled_date IS NULL", reservationID).
+		Order("deposit_date, created_at").
+		Find(&deposits).Error
+	return deposits, err
+}
+
+// SettleDeposit records how a deposit was settled against a bill. Only the
+// settlement columns are written, so the deposit date isn't rewritten in the
+// form it was read back in.
+func (r *ReservationRepository) SettleDeposit(deposit *models.ReservationDeposit) error {
+	return r.db.Model(&models.ReservationDeposit{}).Where("id = ?", deposit.ID).Updates(map[string]interface{}{
+		"bill_id":         deposit.BillID,
+		"applied_amount":  deposit.AppliedAmount,
+		"credited_amount": deposit.CreditedAmount,
+		"refunded_amount": deposit.RefundedAmount,
+		"settled_date":    deposit.SettledDate,
+	}).Error
+}
+
+// FindDepositsBetween returns the deposits received within the given range
+func (r *ReservationRepository) FindDepositsBetween(from, to string) ([]models.ReservationDeposit, error) {
+	var deposits []models.ReservationDeposit
+	err := r.db.Where("deposit_date BETWEEN ? AND ?", from, to).
+		Order("deposit_date, created_at").
+		Find(&deposits).Error
+	return deposits, err
+}
+
+// FindDepositRefundsBetween returns the deposits settled within the given
+// range with part of them refunded
+func (r *ReservationRepository) FindDepositRefundsBetween(from, to string) ([]models.ReservationDeposit, error) {
+	var deposits []models.ReservationDeposit
+	err := r.db.Where("settled_date BETWEEN ? AND ? AND refunded_amount > 0", from, to).
+		Order("settled_date, created_at").
+		Find(&deposits).Error
+	return deposits, err
+}
@@ -1,9 +1,11 @@
 package repository
 
 import (
+	"errors"
 	"trinity-lodge/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SettingsRepository struct {
@@ -14,6 +16,17 @@ func NewSettingsRepository(db *gorm.DB) *SettingsRepository {
 	return &SettingsRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *SettingsRepository) WithTx(tx *gorm.DB) *SettingsRepository {
+	return &SettingsRepository{db: tx}
+}
+
+// Transaction runs fn in a database transaction, rolling back everything it
+// wrote if fn returns an error
+func (r *SettingsRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
 func (r *SettingsRepository) Get() (*models.Settings, error) {
 	var settings models.Settings
 	result := r.db.First(&settings)
@@ -44,40 +57,85 @@ func (r *SettingsRepository) Upsert(settings *models.Settings) error {
 	return r.db.Save(settings).Error
 }
 
-// GetAndIncrementGSTInvoiceNumber atomically gets the next GST invoice number and increments it
-func (r *SettingsRepository) GetAndIncrementGSTInvoiceNumber() (prefix string, number int, err error) {
-	var settings models.Settings
-
+// AllocateInvoiceNumber atomically takes the next number of a series for a
+// financial year and returns it with the settings used to format it. The
+// first number of a series that has never been used continues from the
+// next number stored in settings, so numbering carries on from before
+// counters were kept per financial year.
+func (r *SettingsRepository) AllocateInvoiceNumber(series models.InvoiceSeries, financialYear string) (settings *models.Settings, number int, err error) {
 	err = r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.First(&settings).Error; err != nil {
-			return err
-		}
-
-		prefix = settings.GSTInvoicePrefix
-		number = settings.GSTInvoiceNextNumber
-
-		// Increment the number
-		return tx.Model(&settings).Update("gst_invoice_next_number", settings.GSTInvoiceNextNumber+1).Error
+		settings, number, err = allocateInvoiceNumber(tx, series, financialYear)
+		return err
 	})
-
-	return prefix, number, err
+	return settings, number, err
 }
 
-// GetAndIncrementNonGSTInvoiceNumber atomically gets the next Non-GST invoice number and increments it
-func (r *SettingsRepository) GetAndIncrementNonGSTInvoiceNumber() (prefix string, number int, err error) {
+func allocateInvoiceNumber(tx *gorm.DB, series models.InvoiceSeries, financialYear string) (*models.Settings, int, error) {
 	var settings models.Settings
+	if err := tx.First(&settings).Error; err != nil {
+		return nil, 0, err
+	}
 
-	err = r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.First(&settings).Error; err != nil {
-			return err
+	var counter models.InvoiceCounter
+	err := tx.Where("series = ? AND financial_year = ?", series, financialYear).First(&counter).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		start, err := firstInvoiceNumber(tx, &settings, series)
+		if err != nil {
+			return nil, 0, err
 		}
 
-		prefix = settings.NonGSTInvoicePrefix
-		number = settings.NonGSTInvoiceNextNumber
+		counter = models.InvoiceCounter{Series: series, FinancialYear: financialYear, NextNumber: start + 1}
+		if err := tx.Create(&counter).Error; err != nil {
+			return nil, 0, err
+		}
+		return &settings, start, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
 
-		// Increment the number
-		return tx.Model(&settings).Update("non_gst_invoice_next_number", settings.NonGSTInvoiceNextNumber+1).Error
-	})
+	number := counter.NextNumber
+	if err := tx.Model(&counter).Update("next_number", number+1).Error; err != nil {
+		return nil, 0, err
+	}
+	return &settings, number, nil
+}
+
+// FirstInvoiceNumber returns the number a series starts at in a financial
+// year it has no counter for yet
+func (r *SettingsRepository) FirstInvoiceNumber(settings *models.Settings, series models.InvoiceSeries) (int, error) {
+	return firstInvoiceNumber(r.db, settings, series)
+}
+
+// firstInvoiceNumber is 1, or for a series that has never had a counter the
+// next number stored in settings
+func firstInvoiceNumber(db *gorm.DB, settings *models.Settings, series models.InvoiceSeries) (int, error) {
+	var used int64
+	if err := db.Model(&models.InvoiceCounter{}).Where("series = ?", series).Count(&used).Error; err != nil {
+		return 0, err
+	}
+	if _, _, legacyNext := settings.Series(series); used == 0 && *legacyNext > 0 {
+		return *legacyNext, nil
+	}
+	return 1, nil
+}
+
+// FindInvoiceCounter returns the counter of a series for a financial year
+func (r *SettingsRepository) FindInvoiceCounter(series models.InvoiceSeries, financialYear string) (*models.InvoiceCounter, error) {
+	var counter models.InvoiceCounter
+	err := r.db.Where("series = ? AND financial_year = ?", series, financialYear).First(&counter).Error
+	if err != nil {
+		return nil, err
+	}
+	return &counter, nil
+}
 
-	return prefix, number, err
+// SetInvoiceCounter sets the next number of a series for a financial year,
+// creating the counter if the series hasn't been used that year
+func (r *SettingsRepository) SetInvoiceCounter(series models.InvoiceSeries, financialYear string, nextNumber int) error {
+	counter := models.InvoiceCounter{Series: series, FinancialYear: financialYear, NextNumber: nextNumber}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "series"}, {Name: "financial_year"}},
+		DoUpdates: clause.AssignmentColumns([]string{"next_number", "updated_at"}),
+	}).Create(&counter).Error
 }
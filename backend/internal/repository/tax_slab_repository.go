@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"trinity-lodge/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TaxSlabRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxSlabRepository(db *gorm.DB) *TaxSlabRepository {
+	return &TaxSlabRepository{db: db}
+}
+
+func (r *TaxSlabRepository) Create(slab *models.TaxSlab) error {
+	return r.db.Create(slab).Error
+}
+
+func (r *TaxSlabRepository) FindAll() ([]models.TaxSlab, error) {
+	var slabs []models.TaxSlab
+	err := r.db.Order("item_type, effective_from DESC, min_amount").Find(&slabs).Error
+	return slabs, err
+}
+
+func (r *TaxSlabRepository) FindByID(id uuid.UUID) (*models.TaxSlab, error) {
+	var slab models.TaxSlab
+	err := r.db.First(&slab, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &slab, nil
+}
+
+// FindEffective returns the slabs for an item type that are in force on the given date
+func (r *TaxSlabRepository) FindEffective(itemType models.LineItemType, date string) ([]models.TaxSlab, error) {
+	var slabs []models.TaxSlab
+	err := r.db.Where("item_type = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to >= ?)",
+		itemType, date, date).
+		Order("effective_from DESC, min_amount").
+		Find(&slabs).Error
+	return slabs, err
+}
+
+func (r *TaxSlabRepository) Update(slab *models.TaxSlab) error {
+	return r.db.Save(slab).Error
+}
+
+func (r *TaxSlabRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.TaxSlab{}, "id = ?", id).Error
+}
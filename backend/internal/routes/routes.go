@@ -8,13 +8,22 @@ import (
 )
 
 type Handlers struct {
-	Auth        *handlers.AuthHandler
-	Customer    *handlers.CustomerHandler
-	Room        *handlers.RoomHandler
-	Reservation *handlers.ReservationHandler
-	Bill        *handlers.BillHandler
-	Payment     *handlers.PaymentHandler
-	Settings    *handlers.SettingsHandler
+	Auth          *handlers.AuthHandler
+	Customer      *handlers.CustomerHandler
+	Room          *handlers.RoomHandler
+	Reservation   *handlers.ReservationHandler
+	Bill          *handlers.BillHandler
+	Payment       *handlers.PaymentHandler
+	Settings      *handlers.SettingsHandler
+	TaxSlab       *handlers.TaxSlabHandler
+	Report        *handlers.ReportHandler
+	Receipt       *handlers.ReceiptHandler
+	PaymentMethod *handlers.PaymentMethodHandler
+	Folio         *handlers.FolioHandler
+	Catalog       *handlers.CatalogHandler
+	KitchenOrder  *handlers.KitchenOrderHandler
+	Pricing       *handlers.PricingHandler
+	Availability  *handlers.AvailabilityHandler
 }
 
 func SetupRoutes(router *gin.Engine, h *Handlers, jwtSecret string) {
@@ -38,6 +47,9 @@ func SetupRoutes(router *gin.Engine, h *Handlers, jwtSecret string) {
 			customers.PUT("/:id", h.Customer.Update)
 			customers.DELETE("/:id", h.Customer.Delete)
 			customers.GET("/:id/bills", h.Bill.GetByCustomerID)
+			customers.GET("/:id/credit", h.Customer.GetCredit)
+			customers.GET("/:id/payments", h.Payment.GetByCustomerID)
+			customers.POST("/:id/payments", h.Payment.CreateForCustomer)
 		}
 
 		// Room Types
@@ -46,6 +58,7 @@ func SetupRoutes(router *gin.Engine, h *Handlers, jwtSecret string) {
 			roomTypes.GET("", h.Room.GetAllRoomTypes)
 			roomTypes.POST("", h.Room.CreateRoomType)
 			roomTypes.PUT("/:id", h.Room.UpdateRoomType)
+			roomTypes.GET("/:id/quote", h.Pricing.Quote)
 		}
 
 		// Rooms
@@ -65,6 +78,13 @@ func SetupRoutes(router *gin.Engine, h *Handlers, jwtSecret string) {
 			reservations.PUT("/:id/checkin", h.Reservation.CheckIn)
 			reservations.PUT("/:id/cancel", h.Reservation.Cancel)
 			reservations.PUT("/:id/checkout", h.Reservation.Checkout)
+			reservations.POST("/:id/bill", h.Bill.CreateFromReservation)
+			reservations.GET("/:id/deposits", h.Reservation.GetDeposits)
+			reservations.POST("/:id/deposits", h.Reservation.CreateDeposit)
+			reservations.GET("/:id/folio", h.Folio.Get)
+			reservations.POST("/:id/folio/charges", h.Folio.PostCharge)
+			reservations.DELETE("/:id/folio/charges/:chargeId", h.Folio.RemoveCharge)
+			reservations.POST("/:id/folio/checkout", h.Folio.Checkout)
 		}
 
 		// Bills
@@ -72,9 +92,86 @@ func SetupRoutes(router *gin.Engine, h *Handlers, jwtSecret string) {
 		{
 			bills.POST("", h.Bill.Create)
 			bills.GET("/:id", h.Bill.GetByID)
+			bills.GET("/:id/pdf", h.Bill.GetPDF)
+			bills.GET("/:id/upi-qr", h.Bill.GetUPIQR)
 			bills.POST("/:id/finalize", h.Bill.Finalize)
+			bills.POST("/:id/cancel", h.Bill.Cancel)
+			bills.POST("/:id/credit-notes", h.Bill.CreateCreditNote)
+			bills.POST("/:id/debit-notes", h.Bill.CreateDebitNote)
 			bills.POST("/:id/payments", h.Payment.Create)
+			bills.POST("/:id/payments/split", h.Payment.CreateSplit)
 			bills.GET("/:id/payments", h.Payment.GetByBillID)
+			bills.POST("/:id/payments/:paymentId/refunds", h.Payment.Refund)
+			bills.GET("/:id/receipt", h.Receipt.Bill)
+			bills.POST("/:id/receipt/print", h.Receipt.PrintBill)
+			bills.GET("/:id/payments/:paymentId/receipt", h.Receipt.Payment)
+			bills.POST("/:id/payments/:paymentId/receipt/print", h.Receipt.PrintPayment)
+		}
+
+		// Payments
+		payments := api.Group("/payments")
+		{
+			payments.GET("", h.Payment.Search)
+		}
+
+		// Customer payments settling several bills
+		customerPayments := api.Group("/customer-payments")
+		{
+			customerPayments.GET("/:id", h.Payment.GetCustomerPayment)
+			customerPayments.GET("/:id/receipt", h.Receipt.CustomerPayment)
+			customerPayments.POST("/:id/receipt/print", h.Receipt.PrintCustomerPayment)
+		}
+
+		// Catalog
+		catalog := api.Group("/catalog-items")
+		{
+			catalog.GET("", h.Catalog.GetAll)
+			catalog.GET("/:id", h.Catalog.GetByID)
+			catalog.POST("", middleware.AdminOnly(), h.Catalog.Create)
+			catalog.PUT("/:id", middleware.AdminOnly(), h.Catalog.Update)
+			catalog.DELETE("/:id", middleware.AdminOnly(), h.Catalog.Delete)
+		}
+
+		// Kitchen Orders
+		kitchenOrders := api.Group("/kitchen-orders")
+		{
+			kitchenOrders.GET("", h.KitchenOrder.GetAll)
+			kitchenOrders.POST("", h.KitchenOrder.Create)
+			kitchenOrders.GET("/:id", h.KitchenOrder.GetByID)
+			kitchenOrders.POST("/:id/items", h.KitchenOrder.AddItem)
+			kitchenOrders.DELETE("/:id/items/:itemId", h.KitchenOrder.RemoveItem)
+			kitchenOrders.GET("/:id/kot", h.KitchenOrder.KOT)
+			kitchenOrders.POST("/:id/kot/print", h.KitchenOrder.PrintKOT)
+			kitchenOrders.POST("/:id/close", h.KitchenOrder.Close)
+			kitchenOrders.POST("/:id/cancel", h.KitchenOrder.Cancel)
+		}
+
+		// Payment Methods
+		paymentMethods := api.Group("/payment-methods")
+		{
+			paymentMethods.GET("", h.PaymentMethod.GetAll)
+			paymentMethods.POST("", middleware.AdminOnly(), h.PaymentMethod.Create)
+			paymentMethods.PUT("/:id", middleware.AdminOnly(), h.PaymentMethod.Update)
+		}
+
+		// Rate Plans
+		ratePlans := api.Group("/rate-plans")
+		{
+			ratePlans.GET("", h.Pricing.GetAll)
+			ratePlans.GET("/:id", h.Pricing.GetByID)
+			ratePlans.POST("", middleware.AdminOnly(), h.Pricing.Create)
+			ratePlans.PUT("/:id", middleware.AdminOnly(), h.Pricing.Update)
+			ratePlans.DELETE("/:id", middleware.AdminOnly(), h.Pricing.Delete)
+		}
+
+		// Availability
+		api.GET("/availability", h.Availability.Search)
+
+		// Reports
+		reports := api.Group("/reports")
+		{
+			reports.GET("/revenue", h.Report.Revenue)
+			reports.GET("/collections", h.Report.Collections)
 		}
 
 		// Settings
@@ -82,6 +179,17 @@ func SetupRoutes(router *gin.Engine, h *Handlers, jwtSecret string) {
 		{
 			settings.GET("", h.Settings.Get)
 			settings.POST("", h.Settings.Save)
+			settings.POST("/printers", middleware.AdminOnly(), h.Settings.SavePrinters)
+			settings.POST("/upi", middleware.AdminOnly(), h.Settings.SaveUPI)
+		}
+
+		// Tax Slabs
+		taxSlabs := api.Group("/tax-slabs")
+		{
+			taxSlabs.GET("", h.TaxSlab.GetAll)
+			taxSlabs.POST("", middleware.AdminOnly(), h.TaxSlab.Create)
+			taxSlabs.PUT("/:id", middleware.AdminOnly(), h.TaxSlab.Update)
+			taxSlabs.DELETE("/:id", middleware.AdminOnly(), h.TaxSlab.Delete)
 		}
 	}
 }
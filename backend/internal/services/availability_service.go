@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RoomTypeAvailability is the rooms of one type that are free for a stay
+// and what the stay would cost in them
+type RoomTypeAvailability struct {
+	RoomType  models.RoomType `json:"room_type"`
+	Available int             `json:"available"`
+	Rooms     []models.Room   `json:"rooms"`
+	Quote     *Quote          `json:"quote"`
+}
+
+// Availability answers an enquiry for a stay: the free rooms of each room
+// type, with a quote per type
+type Availability struct {
+	CheckIn      string                 `json:"check_in"`
+	CheckOut     string                 `json:"check_out"`
+	Occupancy    int                    `json:"occupancy"`
+	RatePlanCode string                 `json:"rate_plan_code,omitempty"`
+	RoomTypes    []RoomTypeAvailability `json:"room_types"`
+}
+
+type AvailabilityService struct {
+	roomRepo        *repository.RoomRepository
+	reservationRepo *repository.ReservationRepository
+	ratePlanRepo    *repository.RatePlanRepository
+}
+
+func NewAvailabilityService(roomRepo *repository.RoomRepository, reservationRepo *repository.ReservationRepository, ratePlanRepo *repository.RatePlanRepository) *AvailabilityService {
+	return &AvailabilityService{
+		roomRepo:        roomRepo,
+		reservationRepo: reservationRepo,
+		ratePlanRepo:    ratePlanRepo,
+	}
+}
+
+// Search lists the rooms free from checkIn up to the checkout date, by room
+// type, or only of roomTypeID when it is given. Rooms under maintenance and
+// rooms with an active reservation overlapping the stay are left out. Room
+// types with no free rooms are listed with none, so staff can see they are
+// full. The quote is priced the same way as Quote.
+func (s *AvailabilityService) Search(checkIn, checkOut string, roomTypeID *uuid.UUID, occupancy int, code string) (*Availability, error) {
+	start, err := parseDate(checkIn)
+	if err != nil {
+		return nil, ErrInvalidQuoteDates
+	}
+	end, err := parseDate(checkOut)
+	if err != nil {
+		return nil, ErrInvalidQuoteDates
+	}
+	if !end.After(start) {
+		return nil, ErrInvalidStayDates
+	}
+	if occupancy == 0 {
+		occupancy = 1
+	}
+	if occupancy < 0 {
+		return nil, ErrInvalidOccupancy
+	}
+
+	var roomTypes []models.RoomType
+	if roomTypeID != nil {
+		roomType, err := s.roomRepo.FindRoomTypeByID(*roomTypeID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoomTypeNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		roomTypes = []models.RoomType{*roomType}
+	} else {
+		roomTypes, err = s.roomRepo.FindAllRoomTypes()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rooms, err := s.roomRepo.FindAllRooms()
+	if err != nil {
+		return nil, err
+	}
+	bookedIDs, err := s.reservationRepo.FindBookedRoomIDs(formatDate(start), formatDate(end))
+	if err != nil {
+		return nil, err
+	}
+	booked := make(map[uuid.UUID]bool, len(bookedIDs))
+	for _, id := range bookedIDs {
+		booked[id] = true
+	}
+
+	freeRooms := make(map[uuid.UUID][]models.Room)
+	for _, room := range rooms {
+		if room.Status == models.RoomStatusMaintenance || booked[room.ID] {
+			continue
+		}
+		room.Type = nil
+		freeRooms[room.TypeID] = append(freeRooms[room.TypeID], room)
+	}
+
+	code = normalizeRateCode(code)
+	codeFound := false
+	availability := &Availability{
+		CheckIn:      formatDate(start),
+		CheckOut:     formatDate(end),
+		Occupancy:    occupancy,
+		RatePlanCode: code,
+		RoomTypes:    []RoomTypeAvailability{},
+	}
+	for i := range roomTypes {
+		roomType := &roomTypes[i]
+		plans, err := s.ratePlanRepo.FindForRoomType(roomType.ID)
+		if err != nil {
+			return nil, err
+		}
+		if code != "" && hasRateCode(plans, code) {
+			codeFound = true
+		}
+
+		free := freeRooms[roomType.ID]
+		if free == nil {
+			free = []models.Room{}
+		}
+		availability.RoomTypes = append(availability.RoomTypes, RoomTypeAvailability{
+			RoomType:  *roomType,
+			Available: len(free),
+			Rooms:     free,
+			Quote:     quoteStay(roomType, plans, start, end, occupancy, code),
+		})
+	}
+
+	// A code that no room type has would quietly quote the standard rates
+	if code != "" && !codeFound {
+		return nil, ErrUnknownRatePlanCode
+	}
+	return availability, nil
+}
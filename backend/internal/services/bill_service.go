@@ -1,44 +1,211 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"time"
+	"trinity-lodge/internal/invoice"
 	"trinity-lodge/internal/models"
 	"trinity-lodge/internal/repository"
+	"trinity-lodge/internal/upi"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidDiscount = errors.New("discount cannot be negative or exceed the amount it applies to")
+	ErrInvalidTaxRate  = errors.New("tax rate must be between 0 and 100")
+	ErrInvalidLineItem = errors.New("line item quantity and unit price cannot be negative")
+	ErrNoTaxSlab       = errors.New("no tax slab configured")
+
+	ErrInvalidBillStatus = errors.New("new bills must be DRAFT or FINALIZED")
+	ErrInvalidBillDate   = errors.New("invalid bill date")
+
+	ErrBillNotFound        = errors.New("bill not found")
+	ErrBillLocked          = repository.ErrBillLocked
+	ErrBillNotDraft        = errors.New("only draft bills can be finalized")
+	ErrNoteReasonRequired  = errors.New("a reason is required for credit and debit notes")
+	ErrNoteOnDraft         = errors.New("draft bills should be edited directly rather than corrected with a note")
+	ErrNoteOnNote          = errors.New("credit and debit notes can only be issued against an invoice")
+	ErrCreditExceedsAmount = errors.New("credit notes cannot exceed the invoice total after earlier adjustments")
+	ErrNoteLineNotOnBill   = errors.New("each note line must reference a line of the original invoice")
+	ErrNoteRateMismatch    = errors.New("a note line must carry the tax rate of the invoice line it adjusts")
+	ErrBillCancelled       = errors.New("bill is cancelled")
+	ErrCancelReasonMissing = errors.New("a reason is required to cancel a bill")
+	ErrBillHasPayments     = errors.New("bills with payments cannot be cancelled until the payments are reversed")
+	ErrBillHasNotes        = errors.New("bills with credit or debit notes cannot be cancelled")
+
+	ErrUPINotConfigured = errors.New("no UPI VPA is configured in settings")
+	ErrNothingDue       = errors.New("bill has no outstanding balance")
+
+	ErrReservationNotFound      = errors.New("reservation not found")
+	ErrReservationNotBillable   = errors.New("cancelled reservations cannot be billed")
+	ErrReservationAlreadyBilled = errors.New("a room bill already exists for this reservation")
+	ErrChargesBilled            = repository.ErrChargesBilled
+	ErrInvalidStayDates         = errors.New("checkout date must be after the check-in date")
+	ErrInvalidDepositLeftover   = errors.New("deposit leftover must be CREDIT or REFUND")
 )
 
 type BillService struct {
-	repo         *repository.BillRepository
-	settingsRepo *repository.SettingsRepository
+	repo            *repository.BillRepository
+	settingsRepo    *repository.SettingsRepository
+	customerRepo    *repository.CustomerRepository
+	taxSlabRepo     *repository.TaxSlabRepository
+	reservationRepo *repository.ReservationRepository
+	paymentRepo     *repository.PaymentRepository
+	creditRepo      *repository.CustomerCreditRepository
+	folioRepo       *repository.FolioRepository
+	catalogRepo     *repository.CatalogRepository
+	ratePlanRepo    *repository.RatePlanRepository
+}
+
+// RoomBillOptions controls how a room bill is generated from a reservation
+type RoomBillOptions struct {
+	CheckoutDate  string        // defaults to the actual, then expected, checkout date
+	BillDate      string        // defaults to today
+	NightlyRate   *models.Money // overrides the rates quoted for each night
+	IsGSTBill     bool
+	PlaceOfSupply string
+	GeneratedBy   uuid.UUID
+	// DepositLeftover decides what happens to advance deposits the bill
+	// doesn't use up; defaults to crediting them to the guest
+	DepositLeftover models.DepositLeftover
 }
 
-func NewBillService(repo *repository.BillRepository, settingsRepo *repository.SettingsRepository) *BillService {
-	return &BillService{repo: repo, settingsRepo: settingsRepo}
+func NewBillService(
+	repo *repository.BillRepository,
+	settingsRepo *repository.SettingsRepository,
+	customerRepo *repository.CustomerRepository,
+	taxSlabRepo *repository.TaxSlabRepository,
+	reservationRepo *repository.ReservationRepository,
+	paymentRepo *repository.PaymentRepository,
+	creditRepo *repository.CustomerCreditRepository,
+	folioRepo *repository.FolioRepository,
+	catalogRepo *repository.CatalogRepository,
+	ratePlanRepo *repository.RatePlanRepository,
+) *BillService {
+	return &BillService{
+		repo:            repo,
+		settingsRepo:    settingsRepo,
+		customerRepo:    customerRepo,
+		taxSlabRepo:     taxSlabRepo,
+		reservationRepo: reservationRepo,
+		paymentRepo:     paymentRepo,
+		creditRepo:      creditRepo,
+		folioRepo:       folioRepo,
+		catalogRepo:     catalogRepo,
+		ratePlanRepo:    ratePlanRepo,
+	}
 }
 
+// CreateBill computes the bill's totals from its line items, assigns the next
+// invoice number and stores the bill. Any subtotal, tax or total already set
+// on the bill is ignored; a discount set on the bill is spread across the
+// line items on top of their own discounts.
 func (s *BillService) CreateBill(bill *models.Bill, lineItems []models.BillLineItem) error {
-	// Generate invoice number based on whether it's a GST bill
-	var prefix string
-	var number int
-	var err error
+	if err := prepareNewBill(bill); err != nil {
+		return err
+	}
+	if err := s.applyCatalogItems(lineItems); err != nil {
+		return err
+	}
+	if err := s.calculateTotals(bill, lineItems); err != nil {
+		return err
+	}
 
-	if bill.IsGSTBill {
-		prefix, number, err = s.settingsRepo.GetAndIncrementGSTInvoiceNumber()
-	} else {
-		prefix, number, err = s.settingsRepo.GetAndIncrementNonGSTInvoiceNumber()
+	// A room bill for a reservation takes the deposits held on it, as a
+	// generated room bill does, crediting what it doesn't need to the guest
+	if !isRoomInvoice(bill) || bill.ReservationID == nil {
+		return s.insertBill(bill, lineItems)
 	}
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := s.insertBillTx(tx, bill, lineItems); err != nil {
+			return err
+		}
+		return s.applyDeposits(tx, bill, models.DepositLeftoverCredit)
+	})
+	if err != nil {
+		bill.ID = uuid.Nil
+		bill.InvoiceNumber = ""
+		return err
+	}
+	return nil
+}
+
+// CreatePricedBillTx creates a bill like CreateBill in tx, from lines that
+// already carry their price, tax rate and HSN/SAC code, such as kitchen
+// orders priced when they were ordered. Lines keep their catalog item
+// reference but aren't repriced from the catalog.
+func (s *BillService) CreatePricedBillTx(tx *gorm.DB, bill *models.Bill, lineItems []models.BillLineItem) error {
+	if err := prepareNewBill(bill); err != nil {
+		return err
+	}
+	if err := s.calculateTotals(bill, lineItems); err != nil {
+		return err
+	}
+
+	return s.insertBillTx(tx, bill, lineItems)
+}
+
+// prepareNewBill defaults a new bill to a draft invoice and checks the
+// status it is created in
+func prepareNewBill(bill *models.Bill) error {
+	if bill.DocumentType == "" {
+		bill.DocumentType = models.DocumentTypeInvoice
+	}
+
+	switch bill.Status {
+	case "":
+		bill.Status = models.BillStatusDraft
+	case models.BillStatusDraft, models.BillStatusFinalized:
+	default:
+		return ErrInvalidBillStatus
+	}
+	return nil
+}
 
+// insertBill numbers a bill whose totals have already been calculated and
+// stores it with its line items. The invoice number is allocated in the same
+// transaction as the inserts, so a failed insert never burns a number or
+// leaves a half-written bill behind.
+func (s *BillService) insertBill(bill *models.Bill, lineItems []models.BillLineItem) error {
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		return s.insertBillTx(tx, bill, lineItems)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to generate invoice number: %w", err)
+		bill.ID = uuid.Nil
+		bill.InvoiceNumber = ""
+		return err
 	}
+	return nil
+}
 
-	// Format invoice number: PREFIX-0001
-	bill.InvoiceNumber = fmt.Sprintf("%s-%04d", prefix, number)
+// insertBillTx does the work of insertBill inside tx
+func (s *BillService) insertBillTx(tx *gorm.DB, bill *models.Bill, lineItems []models.BillLineItem) error {
+	billDate, err := parseDate(bill.BillDate)
+	if err != nil {
+		return fmt.Errorf("%w: bill date must be a YYYY-MM-DD date", ErrInvalidBillDate)
+	}
 
-	// Create bill first
-	err = s.repo.Create(bill)
+	// Number the bill from its series' counter for the bill date's financial year
+	series := invoiceSeriesFor(bill)
+	settings, number, err := s.settingsRepo.WithTx(tx).AllocateInvoiceNumber(series, financialYear(billDate))
 	if err != nil {
+		return fmt.Errorf("failed to generate invoice number: %w", err)
+	}
+
+	prefix, format, _ := settings.Series(series)
+	if format == "" {
+		format = defaultInvoiceFormat
+	}
+	bill.InvoiceNumber = formatInvoiceNumber(format, prefix, billDate, number)
+
+	// Nothing has been paid on a new bill, so the whole total is due
+	bill.ApplyPayments(0, 0)
+
+	bills := s.repo.WithTx(tx)
+	if err := bills.Create(bill); err != nil {
 		return err
 	}
 
@@ -47,18 +214,606 @@ func (s *BillService) CreateBill(bill *models.Bill, lineItems []models.BillLineI
 		lineItems[i].BillID = bill.ID
 	}
 
-	// Create line items
 	if len(lineItems) > 0 {
-		return s.repo.CreateLineItems(lineItems)
+		if err := bills.CreateLineItems(lineItems); err != nil {
+			return err
+		}
 	}
+	bill.LineItems = lineItems
 
+	// A credit note reduces what is due on the invoice it corrects
+	if bill.DocumentType == models.DocumentTypeCreditNote && bill.OriginalBillID != nil {
+		if _, err := refreshSettlement(bills, s.paymentRepo.WithTx(tx), *bill.OriginalBillID); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// CreateRoomBillFromReservation generates a draft ROOM bill for a reservation
+// with one line per night of the stay, from the actual check-in date to the
+// checkout date, followed by any unbilled charges on its folio. Advance
+// deposits held on the reservation are applied to the bill as payments;
+// whatever the bill doesn't need is refunded or credited to the guest as
+// opts say.
+func (s *BillService) CreateRoomBillFromReservation(reservationID uuid.UUID, opts RoomBillOptions) (*models.Bill, error) {
+	bills, err := s.CheckoutFolio(reservationID, opts, false)
+	if err != nil {
+		return nil, err
+	}
+	return bills[0], nil
+}
+
+// CheckoutFolio bills a reservation at checkout and closes its folio. The
+// stay and the folio's unbilled charges go on a single draft ROOM bill, or
+// with split the stay goes on the ROOM bill and the charges on separate
+// draft FOOD and MANUAL bills by their bill type. Deposits are applied to
+// the ROOM bill as in CreateRoomBillFromReservation. All the bills are
+// created in one transaction, so either all of them exist or none do.
+func (s *BillService) CheckoutFolio(reservationID uuid.UUID, opts RoomBillOptions, split bool) ([]*models.Bill, error) {
+	switch opts.DepositLeftover {
+	case "":
+		opts.DepositLeftover = models.DepositLeftoverCredit
+	case models.DepositLeftoverCredit, models.DepositLeftoverRefund:
+	default:
+		return nil, ErrInvalidDepositLeftover
+	}
+
+	reservation, err := s.reservationRepo.FindByID(reservationID)
+	if err != nil {
+		return nil, ErrReservationNotFound
+	}
+
+	if reservation.Status == models.ReservationStatusCancelled {
+		return nil, ErrReservationNotBillable
+	}
+
+	if err := checkNotRoomBilled(s.repo, reservationID); err != nil {
+		return nil, err
+	}
+
+	lineItems, err := s.stayLineItems(reservation, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	folio, err := s.folioRepo.FindByReservationID(reservationID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	billDate := opts.BillDate
+	if billDate == "" {
+		billDate = formatDate(time.Now())
+	}
+	newBill := func(billType models.BillType) *models.Bill {
+		return &models.Bill{
+			ID:            uuid.New(),
+			CustomerID:    reservation.CustomerID,
+			ReservationID: &reservation.ID,
+			BillType:      billType,
+			DocumentType:  models.DocumentTypeInvoice,
+			BillDate:      billDate,
+			IsGSTBill:     opts.IsGSTBill,
+			PlaceOfSupply: opts.PlaceOfSupply,
+			Status:        models.BillStatusDraft,
+			GeneratedBy:   opts.GeneratedBy,
+		}
+	}
+
+	// The room bill comes first, then a bill per kind of charge when split
+	roomBill := &folioBill{bill: newBill(models.BillTypeRoom), lineItems: lineItems}
+	folioBills := []*folioBill{roomBill}
+	if folio != nil {
+		byType := make(map[models.BillType]*folioBill)
+		for _, charge := range folio.Charges {
+			if charge.BillID != nil {
+				continue
+			}
+			target := roomBill
+			if split {
+				if byType[charge.BillType] == nil {
+					byType[charge.BillType] = &folioBill{bill: newBill(charge.BillType)}
+					folioBills = append(folioBills, byType[charge.BillType])
+				}
+				target = byType[charge.BillType]
+			}
+			target.lineItems = append(target.lineItems, chargeLineItem(charge))
+			target.chargeIDs = append(target.chargeIDs, charge.ID)
+		}
+	}
+
+	for _, fb := range folioBills {
+		if err := s.calculateTotals(fb.bill, fb.lineItems); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		// Another checkout may have billed the stay since the check above
+		if err := checkNotRoomBilled(s.repo.WithTx(tx), reservationID); err != nil {
+			return err
+		}
+		folios := s.folioRepo.WithTx(tx)
+		for _, fb := range folioBills {
+			if err := s.insertBillTx(tx, fb.bill, fb.lineItems); err != nil {
+				return err
+			}
+			if err := folios.MarkChargesBilled(fb.chargeIDs, fb.bill.ID); err != nil {
+				return err
+			}
+		}
+		if folio != nil {
+			if err := folios.SetStatus(folio.ID, models.FolioStatusClosed); err != nil {
+				return err
+			}
+		}
+		return s.applyDeposits(tx, roomBill.bill, opts.DepositLeftover)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bills := make([]*models.Bill, len(folioBills))
+	for i, fb := range folioBills {
+		bills[i] = fb.bill
+	}
+	return bills, nil
+}
+
+// folioBill is a bill being assembled at checkout, with the folio charges
+// that go on it
+type folioBill struct {
+	bill      *models.Bill
+	lineItems []models.BillLineItem
+	chargeIDs []uuid.UUID
+}
+
+// stayLineItems returns one ROOM line per night of a reservation, from the
+// actual check-in date to the checkout date, priced from the quote for the
+// room type, occupancy and rate plan code of the reservation
+func (s *BillService) stayLineItems(reservation *models.Reservation, opts RoomBillOptions) ([]models.BillLineItem, error) {
+	checkIn := reservation.CheckInDate
+	if reservation.ActualCheckInDate != nil {
+		checkIn = *reservation.ActualCheckInDate
+	}
+	checkOut := opts.CheckoutDate
+	if checkOut == "" && reservation.ActualCheckOutDate != nil {
+		checkOut = *reservation.ActualCheckOutDate
+	}
+	if checkOut == "" {
+		checkOut = reservation.ExpectedCheckOutDate
+	}
+
+	start, err := parseDate(checkIn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid check-in date: %w", err)
+	}
+	end, err := parseDate(checkOut)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkout date: %w", err)
+	}
+	if !end.After(start) {
+		return nil, ErrInvalidStayDates
+	}
+
+	if reservation.Room == nil || reservation.Room.Type == nil {
+		return nil, errors.New("reservation has no room type to bill")
+	}
+
+	plans, err := s.ratePlanRepo.FindForRoomType(reservation.Room.TypeID)
+	if err != nil {
+		return nil, err
+	}
+	occupancy := max(reservation.Occupancy, 1)
+	quote := quoteStay(reservation.Room.Type, plans, start, end, occupancy, normalizeRateCode(reservation.RatePlanCode))
+	roomLabel := fmt.Sprintf("Room %s - %s", reservation.Room.RoomNumber, reservation.Room.Type.Name)
+
+	var lineItems []models.BillLineItem
+	for _, night := range quote.Nights {
+		date, _ := parseDate(night.Date)
+		nightOf := "night of " + date.Format("02 Jan 2006")
+		rate := night.Rate
+		if opts.NightlyRate != nil {
+			rate = *opts.NightlyRate
+		} else if night.RatePlanID != nil {
+			nightOf += ", " + night.RatePlanName
+		}
+		lineItems = append(lineItems, models.BillLineItem{
+			ItemType:    models.LineItemTypeRoom,
+			Description: fmt.Sprintf("%s (%s)", roomLabel, nightOf),
+			Quantity:    1,
+			UnitPrice:   rate,
+		})
+	}
+	return lineItems, nil
+}
+
+// chargeLineItem turns a folio charge into a bill line dated with the day
+// it was incurred
+func chargeLineItem(charge models.FolioCharge) models.BillLineItem {
+	description := charge.Description
+	if date, err := parseDate(charge.ChargeDate); err == nil {
+		description = fmt.Sprintf("%s (%s)", description, date.Format("02 Jan 2006"))
+	}
+	return models.BillLineItem{
+		ItemType:      models.LineItemTypeOther,
+		Description:   description,
+		HSNSACCode:    charge.HSNSACCode,
+		Quantity:      charge.Quantity,
+		UnitPrice:     charge.UnitPrice,
+		TaxRate:       charge.TaxRate,
+		CatalogItemID: charge.CatalogItemID,
+	}
+}
+
+// applyDeposits settles the held deposits of a bill's reservation against
+// the bill, oldest first. Each deposit becomes a payment dated when the
+// deposit was received, for as much of it as the balance still needs; the
+// rest is refunded or put on the guest's credit account.
+func (s *BillService) applyDeposits(tx *gorm.DB, bill *models.Bill, leftover models.DepositLeftover) error {
+	reservations := s.reservationRepo.WithTx(tx)
+	deposits, err := reservations.FindHeldDeposits(*bill.ReservationID)
+	if err != nil || len(deposits) == 0 {
+		return err
+	}
+
+	bills := s.repo.WithTx(tx)
+	payments := s.paymentRepo.WithTx(tx)
+	due := bill.BalanceDue
+	for i := range deposits {
+		deposit := &deposits[i]
+
+		// Payments are filtered by plain dates
+		depositDate, err := parseDate(deposit.DepositDate)
+		if err != nil {
+			return fmt.Errorf("invalid deposit date: %w", err)
+		}
+
+		applied := min(deposit.Amount, due)
+		if applied > 0 {
+			payment := &models.Payment{
+				BillID:          bill.ID,
+				Amount:          applied,
+				PaymentMethod:   deposit.PaymentMethod,
+				PaymentDate:     formatDate(depositDate),
+				ReferenceNumber: deposit.ReferenceNumber,
+				Note:            "Advance received",
+				DepositID:       &deposit.ID,
+			}
+			if err := payments.Create(payment); err != nil {
+				return err
+			}
+			due -= applied
+		}
+
+		rest := deposit.Amount - applied
+		if rest > 0 && leftover == models.DepositLeftoverCredit {
+			credit := &models.CustomerCredit{
+				CustomerID:  deposit.CustomerID,
+				Amount:      rest,
+				BillID:      &bill.ID,
+				Description: "Unused advance on " + bill.InvoiceNumber,
+			}
+			if err := s.creditRepo.WithTx(tx).Create(credit); err != nil {
+				return err
+			}
+			deposit.CreditedAmount = rest
+		} else {
+			deposit.RefundedAmount = rest
+		}
+
+		settled := bill.BillDate
+		deposit.BillID = &bill.ID
+		deposit.AppliedAmount = applied
+		deposit.SettledDate = &settled
+		if err := reservations.SettleDeposit(deposit); err != nil {
+			return err
+		}
+	}
+
+	settledBill, err := refreshSettlement(bills, payments, bill.ID)
+	if err != nil {
+		return err
+	}
+	bill.AmountPaid = settledBill.AmountPaid
+	bill.BalanceDue = settledBill.BalanceDue
+	return nil
+}
+
+// applyCatalogItems fills in the lines that reference a catalog item with
+// the item's current price, tax rate and HSN/SAC code, and its name when the
+// line has no description of its own. The values are copied onto the line,
+// so the bill keeps them if the catalog changes later.
+func (s *BillService) applyCatalogItems(lineItems []models.BillLineItem) error {
+	for i := range lineItems {
+		line := &lineItems[i]
+		if line.CatalogItemID == nil {
+			continue
+		}
+		item, err := activeCatalogItem(s.catalogRepo, *line.CatalogItemID)
+		if err != nil {
+			return err
+		}
+		if line.Description == "" {
+			line.Description = item.Name
+		}
+		line.ItemType = models.LineItemTypeOther
+		line.UnitPrice = item.DefaultPrice
+		line.TaxRate = item.TaxRate
+		line.HSNSACCode = item.HSNSACCode
+	}
+	return nil
+}
+
+// calculateTotals derives the amounts of each line item and from them the
+// subtotal, discount, tax, round-off and total of the bill. ROOM lines are
+// taxed at the slab rate for their nightly tariff; other lines keep their own
+// rate. For GST bills the tax is split into CGST + SGST when the place of
+// supply is the lodge's own state and IGST otherwise.
+func (s *BillService) calculateTotals(bill *models.Bill, lineItems []models.BillLineItem) error {
+	var netAmount models.Money
+	for i := range lineItems {
+		if err := normalizeLineItem(&lineItems[i], bill.BillType); err != nil {
+			return err
+		}
+		netAmount += lineItems[i].Amount
+	}
+
+	// Spread any bill-level discount across the lines
+	if bill.DiscountAmount < 0 || bill.DiscountAmount > netAmount {
+		return ErrInvalidDiscount
+	}
+	shares := allocateDiscount(lineItems, bill.DiscountAmount, netAmount)
+	for i := range lineItems {
+		lineItems[i].DiscountAmount += shares[i]
+		lineItems[i].Amount -= shares[i]
+	}
+
+	var subtotal, discount models.Money
+	for _, item := range lineItems {
+		subtotal += item.Amount + item.DiscountAmount
+		discount += item.DiscountAmount
+	}
+	bill.Subtotal = subtotal
+	bill.DiscountAmount = discount
+
+	bill.TaxAmount, bill.CGSTAmount, bill.SGSTAmount, bill.IGSTAmount = 0, 0, 0, 0
+	bill.IsInterState = false
+
+	settings, err := s.settingsRepo.Get()
+	if err != nil {
+		return fmt.Errorf("failed to load lodge settings: %w", err)
+	}
+
+	if !bill.IsGSTBill {
+		for i := range lineItems {
+			lineItems[i].TaxRate, lineItems[i].TaxAmount = 0, 0
+		}
+		applyRoundOff(bill, bill.Subtotal-bill.DiscountAmount, settings.RoundingMode)
+		return nil
+	}
+
+	if bill.PlaceOfSupply == "" {
+		customer, err := s.customerRepo.FindByID(bill.CustomerID)
+		if err != nil {
+			return fmt.Errorf("failed to load customer: %w", err)
+		}
+		bill.PlaceOfSupply = customer.StateCode
+	}
+	// Guests without a recorded state are treated as local supplies
+	if bill.PlaceOfSupply == "" {
+		bill.PlaceOfSupply = settings.StateCode
+	}
+
+	roomSlabs, err := s.taxSlabRepo.FindEffective(models.LineItemTypeRoom, bill.BillDate)
+	if err != nil {
+		return fmt.Errorf("failed to load tax slabs: %w", err)
+	}
+
+	var taxAmount models.Money
+	for i := range lineItems {
+		item := &lineItems[i]
+		// Notes carry the rates of the invoice they adjust, so slabs only
+		// apply to invoices
+		if item.ItemType == models.LineItemTypeRoom && bill.DocumentType == models.DocumentTypeInvoice {
+			rate, ok := slabRate(roomSlabs, item.UnitPrice)
+			if !ok {
+				return fmt.Errorf("%w: no slab covers a tariff of %s on %s", ErrNoTaxSlab, item.UnitPrice, bill.BillDate)
+			}
+			item.TaxRate = rate
+		}
+		item.TaxAmount = item.Amount.Percent(item.TaxRate)
+		taxAmount += item.TaxAmount
+	}
+
+	bill.IsInterState = isInterStateSupply(settings.StateCode, bill.PlaceOfSupply)
+	bill.TaxAmount = taxAmount
+	bill.CGSTAmount, bill.SGSTAmount, bill.IGSTAmount = splitGST(bill.TaxAmount, bill.IsInterState)
+	applyRoundOff(bill, bill.Subtotal-bill.DiscountAmount+bill.TaxAmount, settings.RoundingMode)
+	return nil
+}
+
+// applyRoundOff rounds the grand total to a whole rupee and records the
+// adjustment as the bill's round-off, shown as its own line on the invoice
+func applyRoundOff(bill *models.Bill, total models.Money, mode models.RoundingMode) {
+	bill.TotalAmount = total.RoundToRupee(mode)
+	bill.RoundOff = bill.TotalAmount - total
+	bill.AmountInWords = bill.TotalAmount.InWords()
+}
+
+// normalizeLineItem fills in defaults for a line item and computes its amount
+// from quantity, unit price and discount. A line sent with only an amount is
+// treated as a single unit at that price.
+func normalizeLineItem(item *models.BillLineItem, billType models.BillType) error {
+	if item.ItemType == "" {
+		item.ItemType = defaultLineItemType(billType)
+	}
+	if item.ItemType == models.LineItemTypeRoom && item.HSNSACCode == "" {
+		item.HSNSACCode = models.SACRoomAccommodation
+	}
+
+	if item.Quantity == 0 && item.UnitPrice == 0 && item.Amount != 0 {
+		item.Quantity, item.UnitPrice = 1, item.Amount
+	}
+	if item.Quantity == 0 {
+		item.Quantity = 1
+	}
+	if item.Quantity < 0 || item.UnitPrice < 0 {
+		return ErrInvalidLineItem
+	}
+	if item.TaxRate < 0 || item.TaxRate > 100 {
+		return ErrInvalidTaxRate
+	}
+
+	gross := item.UnitPrice.MulQuantity(item.Quantity)
+	if item.DiscountAmount < 0 || item.DiscountAmount > gross {
+		return ErrInvalidDiscount
+	}
+
+	item.Amount = gross - item.DiscountAmount
+	item.TaxAmount = 0
+	return nil
+}
+
+// defaultLineItemType treats untyped lines on a room bill as room tariff lines
+func defaultLineItemType(billType models.BillType) models.LineItemType {
+	if billType == models.BillTypeRoom {
+		return models.LineItemTypeRoom
+	}
+	return models.LineItemTypeOther
+}
+
+// allocateDiscount spreads a bill-level discount across the line items in
+// proportion to their amounts, so each line is taxed on its discounted value.
+// The largest line absorbs any rounding difference.
+func allocateDiscount(lineItems []models.BillLineItem, discount, total models.Money) []models.Money {
+	shares := make([]models.Money, len(lineItems))
+	if discount == 0 || total == 0 {
+		return shares
+	}
+
+	largest := 0
+	remaining := discount
+	for i := range lineItems {
+		if lineItems[i].Amount > lineItems[largest].Amount {
+			largest = i
+		}
+		shares[i] = discount.MulDiv(int64(lineItems[i].Amount), int64(total))
+		remaining -= shares[i]
+	}
+	shares[largest] += remaining
+	return shares
+}
+
 func (s *BillService) GetBillByID(id uuid.UUID) (*models.Bill, error) {
 	return s.repo.FindByID(id)
 }
 
+// InvoiceDocument loads a bill with everything printed on it: its line
+// items, customer, reservation, the lodge settings and, for notes, the
+// number of the invoice they adjust, and how much of the amount paid was
+// advance deposits. A UPI payment request for the balance due is included
+// when a VPA is configured.
+func (s *BillService) InvoiceDocument(id uuid.UUID) (*invoice.Document, error) {
+	bill, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, ErrBillNotFound
+	}
+
+	settings, err := s.settingsRepo.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lodge settings: %w", err)
+	}
+
+	doc := &invoice.Document{Bill: bill, Settings: settings}
+	if bill.OriginalBillID != nil {
+		if original, err := s.repo.FindByID(*bill.OriginalBillID); err == nil {
+			doc.OriginalInvoiceNumber = original.InvoiceNumber
+		}
+	}
+	if bill.ReservationID != nil {
+		payments, err := s.paymentRepo.FindByBillID(bill.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range payments {
+			if p.DepositID != nil {
+				doc.AdvanceReceived += p.Amount - p.Refunded()
+			}
+		}
+	}
+	if settings.UPIVPA != "" && isPayable(bill) && bill.BalanceDue > 0 {
+		doc.UPIPayment = upiPaymentFor(bill, settings, bill.BalanceDue)
+	}
+	return doc, nil
+}
+
+// UPIPayment returns a UPI payment request for the outstanding balance of a
+// bill, for rendering as a QR code
+func (s *BillService) UPIPayment(id uuid.UUID) (*upi.Payment, error) {
+	doc, err := s.InvoiceDocument(id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case doc.Settings.UPIVPA == "":
+		return nil, ErrUPINotConfigured
+	case doc.Bill.Status == models.BillStatusCancelled:
+		return nil, ErrBillCancelled
+	case doc.UPIPayment == nil:
+		return nil, ErrNothingDue
+	}
+	return doc.UPIPayment, nil
+}
+
+// isRoomInvoice reports whether a bill is the live room invoice of its
+// reservation
+func isRoomInvoice(bill *models.Bill) bool {
+	return bill.BillType == models.BillTypeRoom &&
+		bill.DocumentType == models.DocumentTypeInvoice &&
+		bill.Status != models.BillStatusCancelled
+}
+
+// checkNotRoomBilled returns ErrReservationAlreadyBilled when the reservation
+// already has a room invoice
+func checkNotRoomBilled(bills *repository.BillRepository, reservationID uuid.UUID) error {
+	existing, err := bills.FindByReservationID(reservationID)
+	if err != nil {
+		return err
+	}
+	for _, bill := range existing {
+		if isRoomInvoice(&bill) {
+			return ErrReservationAlreadyBilled
+		}
+	}
+	return nil
+}
+
+// isPayable reports whether guests can pay against a bill. Credit notes are
+// refunded rather than paid, and cancelled bills are void.
+func isPayable(bill *models.Bill) bool {
+	return bill.Status != models.BillStatusCancelled && bill.DocumentType != models.DocumentTypeCreditNote
+}
+
+func upiPaymentFor(bill *models.Bill, settings *models.Settings, amount models.Money) *upi.Payment {
+	payee := settings.UPIPayeeName
+	if payee == "" {
+		payee = settings.LodgeName
+	}
+	note := settings.LodgeName
+	if bill.InvoiceNumber != "" {
+		note = "Bill " + bill.InvoiceNumber
+	}
+	return &upi.Payment{
+		VPA:       settings.UPIVPA,
+		PayeeName: payee,
+		Amount:    amount,
+		Reference: bill.InvoiceNumber,
+		Note:      note,
+	}
+}
+
 func (s *BillService) GetBillsByCustomerID(customerID uuid.UUID) ([]models.Bill, error) {
 	return s.repo.FindByCustomerID(customerID)
 }
@@ -67,12 +822,215 @@ func (s *BillService) GetAllBills() ([]models.Bill, error) {
 	return s.repo.FindAll()
 }
 
+// UpdateBill saves changes to a draft bill. Finalized bills are immutable
+// and return ErrBillLocked.
 func (s *BillService) UpdateBill(bill *models.Bill) error {
 	return s.repo.Update(bill)
 }
 
+// FinalizeBill issues a draft bill. It becomes UNPAID, PARTIALLY_PAID or
+// PAID depending on what has already been paid against it.
 func (s *BillService) FinalizeBill(id uuid.UUID) error {
-	return s.repo.UpdateStatus(id, models.BillStatusFinalized)
+	bill, err := s.repo.FindByID(id)
+	if err != nil {
+		return ErrBillNotFound
+	}
+
+	if bill.Status != models.BillStatusDraft {
+		return ErrBillNotDraft
+	}
+
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		bills := s.repo.WithTx(tx)
+		if err := bills.UpdateStatus(id, models.BillStatusFinalized); err != nil {
+			return err
+		}
+		_, err := refreshSettlement(bills, s.paymentRepo.WithTx(tx), id)
+		return err
+	})
+}
+
+// CancelBill voids a bill. The bill and its invoice number are kept so the
+// number series stays gap-free; the bill simply drops out of revenue.
+// Bills with notes against them, or with payments that haven't been fully
+// refunded or reversed, cannot be cancelled.
+func (s *BillService) CancelBill(id uuid.UUID, reason string, cancelledBy uuid.UUID) error {
+	if reason == "" {
+		return ErrCancelReasonMissing
+	}
+
+	bill, err := s.repo.FindByID(id)
+	if err != nil {
+		return ErrBillNotFound
+	}
+	if bill.Status == models.BillStatusCancelled {
+		return ErrBillCancelled
+	}
+
+	for _, note := range bill.Notes {
+		if note.Status != models.BillStatusCancelled {
+			return ErrBillHasNotes
+		}
+	}
+
+	paid, err := s.paymentRepo.TotalForBill(id)
+	if err != nil {
+		return err
+	}
+	if paid != 0 {
+		return ErrBillHasPayments
+	}
+
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		bills := s.repo.WithTx(tx)
+		payments := s.paymentRepo.WithTx(tx)
+		if err := bills.Cancel(id, reason, cancelledBy, time.Now()); err != nil {
+			return err
+		}
+		// Folio charges on the bill can be billed again
+		if err := s.folioRepo.WithTx(tx).ReleaseCharges(id); err != nil {
+			return err
+		}
+		if _, err := refreshSettlement(bills, payments, id); err != nil {
+			return err
+		}
+
+		// Cancelling a credit note puts its amount back on the invoice
+		if bill.DocumentType == models.DocumentTypeCreditNote && bill.OriginalBillID != nil {
+			_, err := refreshSettlement(bills, payments, *bill.OriginalBillID)
+			return err
+		}
+		return nil
+	})
+}
+
+// NoteLine is a line of a credit or debit note. The line's item type, HSN/SAC
+// code and tax rate are copied from the invoice line it references; TaxRate,
+// when given, must match that rate.
+type NoteLine struct {
+	Item    models.BillLineItem
+	TaxRate *float64
+}
+
+// NoteOptions describes a credit or debit note to issue against an invoice
+type NoteOptions struct {
+	DocumentType models.DocumentType
+	Reason       string
+	NoteDate     string // defaults to today
+	GeneratedBy  uuid.UUID
+}
+
+// CreateNote issues a credit or debit note against a finalized invoice. The
+// note inherits the invoice's customer, bill type and tax treatment, and
+// each of its lines the tax rate of the invoice line it adjusts. It is
+// numbered from its own series and is finalized immediately.
+func (s *BillService) CreateNote(originalID uuid.UUID, lines []NoteLine, opts NoteOptions) (*models.Bill, error) {
+	if opts.DocumentType != models.DocumentTypeCreditNote && opts.DocumentType != models.DocumentTypeDebitNote {
+		return nil, fmt.Errorf("unsupported note type %q", opts.DocumentType)
+	}
+	if opts.Reason == "" {
+		return nil, ErrNoteReasonRequired
+	}
+
+	original, err := s.repo.FindByID(originalID)
+	if err != nil {
+		return nil, ErrBillNotFound
+	}
+	if original.DocumentType != models.DocumentTypeInvoice {
+		return nil, ErrNoteOnNote
+	}
+	if original.Status == models.BillStatusCancelled {
+		return nil, ErrBillCancelled
+	}
+	if !original.IsLocked() {
+		return nil, ErrNoteOnDraft
+	}
+
+	noteDate := opts.NoteDate
+	if noteDate == "" {
+		noteDate = formatDate(time.Now())
+	}
+
+	note := &models.Bill{
+		ID:             uuid.New(),
+		CustomerID:     original.CustomerID,
+		ReservationID:  original.ReservationID,
+		BillType:       original.BillType,
+		DocumentType:   opts.DocumentType,
+		OriginalBillID: &original.ID,
+		NoteReason:     opts.Reason,
+		BillDate:       noteDate,
+		IsGSTBill:      original.IsGSTBill,
+		PlaceOfSupply:  original.PlaceOfSupply,
+		Status:         models.BillStatusFinalized,
+		GeneratedBy:    opts.GeneratedBy,
+	}
+
+	lineItems, err := originalLineRates(original, lines)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.calculateTotals(note, lineItems); err != nil {
+		return nil, err
+	}
+
+	if note.DocumentType == models.DocumentTypeCreditNote {
+		// Credits may not take the invoice below zero
+		limit := original.TotalAmount
+		for _, existing := range original.Notes {
+			if existing.Status == models.BillStatusCancelled {
+				continue
+			}
+			switch existing.DocumentType {
+			case models.DocumentTypeCreditNote:
+				limit -= existing.TotalAmount
+			case models.DocumentTypeDebitNote:
+				limit += existing.TotalAmount
+			}
+		}
+		if note.TotalAmount > limit {
+			return nil, ErrCreditExceedsAmount
+		}
+	}
+
+	if err := s.insertBill(note, lineItems); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// originalLineRates gives each note line the item type, HSN/SAC code and tax
+// rate of the invoice line it adjusts, so the note reverses or adds GST at
+// the rate the invoice charged it
+func originalLineRates(original *models.Bill, lines []NoteLine) ([]models.BillLineItem, error) {
+	originalLines := make(map[uuid.UUID]*models.BillLineItem, len(original.LineItems))
+	for i := range original.LineItems {
+		originalLines[original.LineItems[i].ID] = &original.LineItems[i]
+	}
+
+	lineItems := make([]models.BillLineItem, len(lines))
+	for i, line := range lines {
+		item := line.Item
+		if item.OriginalLineItemID == nil {
+			return nil, ErrNoteLineNotOnBill
+		}
+		source, ok := originalLines[*item.OriginalLineItemID]
+		if !ok {
+			return nil, ErrNoteLineNotOnBill
+		}
+		if line.TaxRate != nil && *line.TaxRate != source.TaxRate {
+			return nil, fmt.Errorf("%w: %s was charged at %g%%", ErrNoteRateMismatch, source.Description, source.TaxRate)
+		}
+		if item.Description == "" {
+			item.Description = source.Description
+		}
+		item.ItemType = source.ItemType
+		item.HSNSACCode = source.HSNSACCode
+		item.TaxRate = source.TaxRate
+		item.CatalogItemID = source.CatalogItemID
+		lineItems[i] = item
+	}
+	return lineItems, nil
 }
 
 func (s *BillService) UpdateBillStatus(id uuid.UUID, status models.BillStatus) error {
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"trinity-lodge/internal/config"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestBillService(t *testing.T) (*BillService, *gorm.DB, *models.Customer) {
+	t.Helper()
+
+	db := config.InitDatabase(filepath.Join(t.TempDir(), "test.db"))
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	settingsRepo := repository.NewSettingsRepository(db)
+	if err := settingsRepo.Create(&models.Settings{LodgeName: "Test Lodge", StateCode: "32"}); err != nil {
+		t.Fatalf("create settings: %v", err)
+	}
+
+	customerRepo := repository.NewCustomerRepository(db)
+	customer := &models.Customer{FullName: "Test Guest", Phone: "9000000000", StateCode: "32"}
+	if err := customerRepo.Create(customer); err != nil {
+		t.Fatalf("create customer: %v", err)
+	}
+
+	service := NewBillService(
+		repository.NewBillRepository(db),
+		settingsRepo,
+		customerRepo,
+		repository.NewTaxSlabRepository(db),
+		repository.NewReservationRepository(db),
+		repository.NewPaymentRepository(db),
+		repository.NewCustomerCreditRepository(db),
+		repository.NewFolioRepository(db),
+		repository.NewCatalogRepository(db),
+		repository.NewRatePlanRepository(db),
+	)
+	return service, db, customer
+}
+
+func newTestBill(customerID uuid.UUID) (*models.Bill, []models.BillLineItem) {
+	bill := &models.Bill{
+		CustomerID: customerID,
+		BillType:   models.BillTypeWalkIn,
+		BillDate:   "2025-06-15",
+		IsGSTBill:  true,
+	}
+	lineItems := []models.BillLineItem{
+		{Description: "Laundry", Quantity: 2, UnitPrice: models.Rupees(150), TaxRate: 18},
+	}
+	return bill, lineItems
+}
+
+// Several front-desk terminals creating bills at the same moment must each get
+// their own invoice number, with no gaps in the series.
+func TestCreateBillConcurrentInvoiceNumbers(t *testing.T) {
+	service, db, customer := newTestBillService(t)
+
+	const terminals = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, terminals)
+	numbers := make(chan string, terminals)
+
+	for i := 0; i < terminals; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bill, lineItems := newTestBill(customer.ID)
+			if err := service.CreateBill(bill, lineItems); err != nil {
+				errs <- err
+				return
+			}
+			numbers <- bill.InvoiceNumber
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	close(numbers)
+
+	for err := range errs {
+		t.Errorf("CreateBill: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for number := range numbers {
+		if seen[number] {
+			t.Errorf("invoice number %s issued twice", number)
+		}
+		seen[number] = true
+	}
+	for i := 1; i <= terminals; i++ {
+		want := fmt.Sprintf("GST/25-26/%04d", i)
+		if !seen[want] {
+			t.Errorf("invoice number %s was not issued", want)
+		}
+	}
+
+	var bills, lineItems int64
+	db.Model(&models.Bill{}).Count(&bills)
+	db.Model(&models.BillLineItem{}).Count(&lineItems)
+	if bills != terminals || lineItems != terminals {
+		t.Errorf("stored %d bills and %d line items, want %d of each", bills, lineItems, terminals)
+	}
+}
+
+// A bill whose line items can't be stored must not be left behind, and its
+// invoice number must go to the next bill.
+func TestCreateBillRollsBackOnLineItemFailure(t *testing.T) {
+	service, db, customer := newTestBillService(t)
+
+	bill, lineItems := newTestBill(customer.ID)
+	duplicateID := uuid.New()
+	lineItems = append(lineItems, lineItems[0])
+	lineItems[0].ID = duplicateID
+	lineItems[1].ID = duplicateID
+
+	if err := service.CreateBill(bill, lineItems); err == nil {
+		t.Fatal("CreateBill succeeded with duplicate line item IDs")
+	}
+
+	var bills int64
+	db.Model(&models.Bill{}).Count(&bills)
+	if bills != 0 {
+		t.Errorf("failed bill left %d bills behind", bills)
+	}
+
+	bill, lineItems = newTestBill(customer.ID)
+	if err := service.CreateBill(bill, lineItems); err != nil {
+		t.Fatalf("CreateBill: %v", err)
+	}
+	if bill.InvoiceNumber != "GST/25-26/0001" {
+		t.Errorf("next bill got %s, want GST/25-26/0001", bill.InvoiceNumber)
+	}
+}
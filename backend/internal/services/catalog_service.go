@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCatalogItemNotFound = errors.New("catalog item not found")
+	ErrCatalogItemInactive = errors.New("catalog item is no longer sold")
+	ErrCatalogItemExists   = errors.New("a catalog item with that name already exists")
+	ErrCatalogItemInUse    = errors.New("catalog item has been ordered or charged; deactivate it instead")
+	ErrInvalidCatalogItem  = errors.New("a catalog item needs a name and a price that isn't negative")
+	ErrInvalidCategory     = errors.New("category must be FOOD, BEVERAGE, LAUNDRY, MINIBAR, SERVICE or OTHER")
+)
+
+type CatalogService struct {
+	repo *repository.CatalogRepository
+}
+
+func NewCatalogService(repo *repository.CatalogRepository) *CatalogService {
+	return &CatalogService{repo: repo}
+}
+
+func (s *CatalogService) GetAll(filter repository.CatalogFilter) ([]models.CatalogItem, error) {
+	return s.repo.FindAll(filter)
+}
+
+func (s *CatalogService) GetByID(id uuid.UUID) (*models.CatalogItem, error) {
+	item, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, ErrCatalogItemNotFound
+	}
+	return item, nil
+}
+
+// Create adds an active item to the catalog. Names must be unique
+// regardless of case.
+func (s *CatalogService) Create(item *models.CatalogItem) error {
+	if err := validateCatalogItem(item); err != nil {
+		return err
+	}
+	if _, err := s.repo.FindByName(item.Name); err == nil {
+		return ErrCatalogItemExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	item.IsActive = true
+	return s.repo.Create(item)
+}
+
+// Update changes an item, leaving it active or inactive as it was when
+// isActive is nil. Lines already charged from it keep the price and tax they
+// were charged at.
+func (s *CatalogService) Update(item *models.CatalogItem, isActive *bool) error {
+	existing, err := s.repo.FindByID(item.ID)
+	if err != nil {
+		return ErrCatalogItemNotFound
+	}
+	item.IsActive = existing.IsActive
+	if isActive != nil {
+		item.IsActive = *isActive
+	}
+	if err := validateCatalogItem(item); err != nil {
+		return err
+	}
+	if other, err := s.repo.FindByName(item.Name); err == nil && other.ID != item.ID {
+		return ErrCatalogItemExists
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	item.CreatedAt = existing.CreatedAt
+	return s.repo.Update(item)
+}
+
+// Delete removes an item that has never been charged. Items that have are
+// deactivated instead, so the bills made from them still say where their
+// lines came from.
+func (s *CatalogService) Delete(id uuid.UUID) error {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return ErrCatalogItemNotFound
+	}
+	used, err := s.repo.IsReferenced(id)
+	if err != nil {
+		return err
+	}
+	if used {
+		return ErrCatalogItemInUse
+	}
+	return s.repo.Delete(id)
+}
+
+func validateCatalogItem(item *models.CatalogItem) error {
+	item.Name = strings.TrimSpace(item.Name)
+	item.HSNSACCode = strings.TrimSpace(item.HSNSACCode)
+	if item.Name == "" || item.DefaultPrice < 0 {
+		return ErrInvalidCatalogItem
+	}
+	if !item.Category.IsValid() {
+		return ErrInvalidCategory
+	}
+	if item.TaxRate < 0 || item.TaxRate > 100 {
+		return ErrInvalidTaxRate
+	}
+	return nil
+}
+
+// activeCatalogItem loads a catalog item that can still be charged for
+func activeCatalogItem(repo *repository.CatalogRepository, id uuid.UUID) (*models.CatalogItem, error) {
+	item, err := repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCatalogItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !item.IsActive {
+		return nil, fmt.Errorf("%w: %s", ErrCatalogItemInactive, item.Name)
+	}
+	return item, nil
+}
@@ -1,18 +1,30 @@
 package services
 
 import (
+	"errors"
 	"trinity-lodge/internal/models"
 	"trinity-lodge/internal/repository"
 
 	"github.com/google/uuid"
 )
 
+var ErrCustomerNotFound = errors.New("customer not found")
+
 type CustomerService struct {
-	repo *repository.CustomerRepository
+	repo       *repository.CustomerRepository
+	creditRepo *repository.CustomerCreditRepository
+}
+
+func NewCustomerService(repo *repository.CustomerRepository, creditRepo *repository.CustomerCreditRepository) *CustomerService {
+	return &CustomerService{repo: repo, creditRepo: creditRepo}
 }
 
-func NewCustomerService(repo *repository.CustomerRepository) *CustomerService {
-	return &CustomerService{repo: repo}
+// CustomerCreditAccount is a customer's available credit and the ledger
+// entries it is made of
+type CustomerCreditAccount struct {
+	CustomerID uuid.UUID               `json:"customer_id"`
+	Balance    models.Money            `json:"balance"`
+	Entries    []models.CustomerCredit `json:"entries"`
 }
 
 func (s *CustomerService) CreateCustomer(customer *models.Customer) error {
@@ -27,6 +39,23 @@ func (s *CustomerService) GetCustomerByID(id uuid.UUID) (*models.Customer, error
 	return s.repo.FindByID(id)
 }
 
+// GetCreditAccount returns the credit held for a customer
+func (s *CustomerService) GetCreditAccount(customerID uuid.UUID) (*CustomerCreditAccount, error) {
+	if _, err := s.repo.FindByID(customerID); err != nil {
+		return nil, ErrCustomerNotFound
+	}
+
+	entries, err := s.creditRepo.FindByCustomerID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := s.creditRepo.Balance(customerID)
+	if err != nil {
+		return nil, err
+	}
+	return &CustomerCreditAccount{CustomerID: customerID, Balance: balance, Entries: entries}, nil
+}
+
 func (s *CustomerService) UpdateCustomer(customer *models.Customer) error {
 	return s.repo.Update(customer)
 }
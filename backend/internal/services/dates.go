@@ -0,0 +1,18 @@
+package services
+
+import (
+	"time"
+	"trinity-lodge/internal/models"
+)
+
+const dateLayout = models.DateLayout
+
+// parseDate parses a YYYY-MM-DD date, as entered or as stored
+func parseDate(value string) (time.Time, error) {
+	return time.Parse(dateLayout, models.DateOnly(value))
+}
+
+// formatDate formats a date as YYYY-MM-DD
+func formatDate(t time.Time) string {
+	return t.Format(dateLayout)
+}
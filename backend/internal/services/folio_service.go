@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFolioNotFound       = errors.New("reservation has no folio")
+	ErrFolioClosed         = errors.New("folio is closed")
+	ErrReservationNotOpen  = errors.New("charges can only be posted to active reservations")
+	ErrFolioChargeNotFound = errors.New("folio charge not found")
+	ErrFolioChargeBilled   = errors.New("billed charges cannot be removed from the folio")
+	ErrInvalidFolioCharge  = errors.New("a charge needs a description, a positive quantity and a price that isn't negative")
+	ErrInvalidChargeType   = errors.New("charge bill type must be FOOD or MANUAL")
+	ErrInvalidChargeDate   = errors.New("charge date must be a YYYY-MM-DD date")
+)
+
+// FolioService keeps the running folio of each reservation. Folios are
+// billed at checkout through BillService.
+type FolioService struct {
+	repo            *repository.FolioRepository
+	reservationRepo *repository.ReservationRepository
+	catalogRepo     *repository.CatalogRepository
+}
+
+func NewFolioService(repo *repository.FolioRepository, reservationRepo *repository.ReservationRepository, catalogRepo *repository.CatalogRepository) *FolioService {
+	return &FolioService{repo: repo, reservationRepo: reservationRepo, catalogRepo: catalogRepo}
+}
+
+// GetFolio returns a reservation's folio with its charges
+func (s *FolioService) GetFolio(reservationID uuid.UUID) (*models.Folio, error) {
+	folio, err := s.repo.FindByReservationID(reservationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFolioNotFound
+	}
+	return folio, err
+}
+
+// PostCharge adds a charge to the folio of an active reservation, opening the
+// folio with its first charge. The charge date defaults to today and the
+// bill type to MANUAL. A charge for a catalog item takes the item's price,
+// tax rate, HSN/SAC code and bill type, and its name unless described
+// otherwise.
+func (s *FolioService) PostCharge(reservationID uuid.UUID, charge *models.FolioCharge) error {
+	return s.PostCharges(reservationID, []*models.FolioCharge{charge})
+}
+
+// PostCharges adds several charges to a reservation's folio as PostCharge
+// does, all of them or none
+func (s *FolioService) PostCharges(reservationID uuid.UUID, charges []*models.FolioCharge) error {
+	for _, charge := range charges {
+		if err := s.applyCatalogItem(charge); err != nil {
+			return err
+		}
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		return s.PostPricedChargesTx(tx, reservationID, charges)
+	})
+}
+
+// PostPricedChargesTx adds charges that already carry their price, tax rate
+// and bill type, such as kitchen orders priced when they were ordered, in
+// tx. The catalog items they reference aren't looked up again, so they post
+// even if an item has since been deactivated.
+func (s *FolioService) PostPricedChargesTx(tx *gorm.DB, reservationID uuid.UUID, charges []*models.FolioCharge) error {
+	for _, charge := range charges {
+		if err := prepareCharge(charge); err != nil {
+			return err
+		}
+	}
+
+	reservation, err := s.reservationRepo.WithTx(tx).FindByID(reservationID)
+	if err != nil {
+		return ErrReservationNotFound
+	}
+	if reservation.Status != models.ReservationStatusActive {
+		return ErrReservationNotOpen
+	}
+
+	folios := s.repo.WithTx(tx)
+	folio, err := folios.FindByReservationID(reservationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		folio = &models.Folio{
+			ReservationID: reservationID,
+			CustomerID:    reservation.CustomerID,
+			Status:        models.FolioStatusOpen,
+		}
+		err = folios.Create(folio)
+	}
+	if err != nil {
+		return err
+	}
+	if folio.Status == models.FolioStatusClosed {
+		return ErrFolioClosed
+	}
+
+	for _, charge := range charges {
+		charge.FolioID = folio.ID
+		if err := folios.CreateCharge(charge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyCatalogItem fills in the details of a charge for a catalog item from
+// the item
+func (s *FolioService) applyCatalogItem(charge *models.FolioCharge) error {
+	if charge.CatalogItemID == nil {
+		return nil
+	}
+	item, err := activeCatalogItem(s.catalogRepo, *charge.CatalogItemID)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(charge.Description) == "" {
+		charge.Description = item.Name
+	}
+	charge.BillType = item.Category.BillType()
+	charge.UnitPrice = item.DefaultPrice
+	charge.TaxRate = item.TaxRate
+	charge.HSNSACCode = item.HSNSACCode
+	return nil
+}
+
+// prepareCharge fills in a charge's defaults and checks it
+func prepareCharge(charge *models.FolioCharge) error {
+	charge.Description = strings.TrimSpace(charge.Description)
+	if charge.Quantity == 0 {
+		charge.Quantity = 1
+	}
+	if charge.Description == "" || charge.Quantity < 0 || charge.UnitPrice < 0 {
+		return ErrInvalidFolioCharge
+	}
+	if charge.TaxRate < 0 || charge.TaxRate > 100 {
+		return ErrInvalidTaxRate
+	}
+	switch charge.BillType {
+	case "":
+		charge.BillType = models.BillTypeManual
+	case models.BillTypeFood, models.BillTypeManual:
+	default:
+		return ErrInvalidChargeType
+	}
+	if charge.ChargeDate == "" {
+		charge.ChargeDate = formatDate(time.Now())
+	} else if _, err := parseDate(charge.ChargeDate); err != nil {
+		return ErrInvalidChargeDate
+	}
+	charge.Amount = charge.UnitPrice.MulQuantity(charge.Quantity)
+	charge.BillID = nil
+	return nil
+}
+
+// RemoveCharge deletes a charge posted by mistake. Charges already billed
+// have to be corrected on the bill instead.
+func (s *FolioService) RemoveCharge(reservationID, chargeID uuid.UUID) error {
+	folio, err := s.GetFolio(reservationID)
+	if err != nil {
+		return err
+	}
+	for _, charge := range folio.Charges {
+		if charge.ID != chargeID {
+			continue
+		}
+		if charge.BillID != nil {
+			return ErrFolioChargeBilled
+		}
+		return s.repo.DeleteCharge(chargeID)
+	}
+	return ErrFolioChargeNotFound
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"strings"
+	"trinity-lodge/internal/models"
+)
+
+// isInterStateSupply reports whether the place of supply lies outside the
+// lodge's own state, in which case IGST applies instead of CGST + SGST
+func isInterStateSupply(lodgeStateCode, placeOfSupply string) bool {
+	lodge := strings.TrimSpace(lodgeStateCode)
+	place := strings.TrimSpace(placeOfSupply)
+	if lodge == "" || place == "" {
+		return false
+	}
+	return !strings.EqualFold(lodge, place)
+}
+
+// splitGST divides a tax amount into CGST, SGST and IGST components.
+// Intra-state supplies are split evenly between CGST and SGST, with any odd
+// paisa going to SGST so the parts always add up to the total.
+func splitGST(taxAmount models.Money, interState bool) (cgst, sgst, igst models.Money) {
+	if interState {
+		return 0, 0, taxAmount
+	}
+	cgst = taxAmount / 2
+	sgst = taxAmount - cgst
+	return cgst, sgst, 0
+}
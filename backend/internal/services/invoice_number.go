@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+	"trinity-lodge/internal/models"
+)
+
+// Invoice number formats are templates made of literal text and tokens:
+//
+//	{PREFIX}   the series prefix from settings, e.g. GST
+//	{FY}       short financial year, e.g. 25-26
+//	{FY_LONG}  long financial year, e.g. 2025-26
+//	{YYYY}     calendar year of the bill date
+//	{YY}       two-digit calendar year of the bill date
+//	{MM}       two-digit month of the bill date
+//	{SEQ}      sequence number within the financial year
+//	{SEQ:n}    sequence number zero-padded to n digits
+//
+// Counters restart every April, so a format must contain a financial year
+// token as well as the sequence to keep numbers unique.
+var ErrInvalidInvoiceFormat = errors.New("invoice number format must contain {SEQ} or {SEQ:n} and {FY} or {FY_LONG}")
+
+// defaultInvoiceFormat is used for series that have no format configured
+const defaultInvoiceFormat = "{PREFIX}/{FY}/{SEQ:4}"
+
+var invoiceTokenPattern = regexp.MustCompile(`\{(PREFIX|FY|FY_LONG|YYYY|YY|MM|SEQ)(?::(\d{1,2}))?\}`)
+
+// financialYearStart returns the calendar year in which the Indian financial
+// year (April to March) containing date begins
+func financialYearStart(date time.Time) int {
+	if date.Month() < time.April {
+		return date.Year() - 1
+	}
+	return date.Year()
+}
+
+// financialYear returns the financial year containing date, e.g. "2025-26"
+func financialYear(date time.Time) string {
+	start := financialYearStart(date)
+	return fmt.Sprintf("%d-%02d", start, (start+1)%100)
+}
+
+// formatInvoiceNumber expands an invoice number format for a sequence number
+// allocated on the given bill date
+func formatInvoiceNumber(format, prefix string, date time.Time, seq int) string {
+	fyStart := financialYearStart(date)
+	return invoiceTokenPattern.ReplaceAllStringFunc(format, func(token string) string {
+		match := invoiceTokenPattern.FindStringSubmatch(token)
+		switch match[1] {
+		case "PREFIX":
+			return prefix
+		case "FY":
+			return fmt.Sprintf("%02d-%02d", fyStart%100, (fyStart+1)%100)
+		case "FY_LONG":
+			return financialYear(date)
+		case "YYYY":
+			return strconv.Itoa(date.Year())
+		case "YY":
+			return fmt.Sprintf("%02d", date.Year()%100)
+		case "MM":
+			return fmt.Sprintf("%02d", int(date.Month()))
+		case "SEQ":
+			if match[2] != "" {
+				width, _ := strconv.Atoi(match[2])
+				return fmt.Sprintf("%0*d", width, seq)
+			}
+			return strconv.Itoa(seq)
+		}
+		return token
+	})
+}
+
+// validateInvoiceFormat checks that a format yields unique numbers
+func validateInvoiceFormat(format string) error {
+	var hasSeq, hasFY bool
+	for _, match := range invoiceTokenPattern.FindAllStringSubmatch(format, -1) {
+		switch match[1] {
+		case "SEQ":
+			hasSeq = true
+		case "FY", "FY_LONG":
+			hasFY = hasFY || match[2] == ""
+		}
+	}
+	if !hasSeq || !hasFY {
+		return ErrInvalidInvoiceFormat
+	}
+	return nil
+}
+
+// invoiceSeriesFor returns the number series a bill is numbered from
+func invoiceSeriesFor(bill *models.Bill) models.InvoiceSeries {
+	switch {
+	case bill.DocumentType == models.DocumentTypeCreditNote:
+		return models.InvoiceSeriesCreditNote
+	case bill.DocumentType == models.DocumentTypeDebitNote:
+		return models.InvoiceSeriesDebitNote
+	case bill.IsGSTBill:
+		return models.InvoiceSeriesGST
+	default:
+		return models.InvoiceSeriesNonGST
+	}
+}
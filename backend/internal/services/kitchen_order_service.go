@@ -0,0 +1,415 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"trinity-lodge/internal/escpos"
+	"trinity-lodge/internal/invoice"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrKitchenOrderNotFound  = errors.New("kitchen order not found")
+	ErrKitchenOrderNotOpen   = errors.New("kitchen order is already closed or cancelled")
+	ErrKitchenOrderEmpty     = errors.New("kitchen order has no items")
+	ErrInvalidOrderTarget    = errors.New("an order is for either a table_number or a room_id")
+	ErrRoomNotInHouse        = errors.New("room has no checked-in guest")
+	ErrNotKitchenItem        = errors.New("only food and beverage items can be ordered from the kitchen")
+	ErrInvalidOrderQuantity  = errors.New("quantity must be positive")
+	ErrOrderItemNotFound     = errors.New("order item not found")
+	ErrOrderItemSent         = errors.New("items already sent to the kitchen cannot be removed")
+	ErrNothingToSend         = errors.New("every item on the order has already been sent to the kitchen")
+	ErrOrderCustomerRequired = errors.New("a customer_id is required to bill an order that isn't charged to a room")
+)
+
+// KitchenOrderService runs restaurant orders from the first item to the
+// bill. Orders are charged through FolioService for in-house guests and
+// BillService for everyone else, so food invoices are numbered like any
+// other bill.
+type KitchenOrderService struct {
+	repo            *repository.KitchenOrderRepository
+	reservationRepo *repository.ReservationRepository
+	customerRepo    *repository.CustomerRepository
+	catalogRepo     *repository.CatalogRepository
+	settingsRepo    *repository.SettingsRepository
+	folioService    *FolioService
+	billService     *BillService
+}
+
+// CloseOrderOptions says where a closed order is charged. Orders for a room
+// always go to that room's folio; table orders go to ReservationID's folio
+// when it is set, and are otherwise billed to CustomerID.
+type CloseOrderOptions struct {
+	ReservationID *uuid.UUID
+	CustomerID    *uuid.UUID
+	IsGSTBill     bool
+	PlaceOfSupply string
+	Status        models.BillStatus // of the bill; DRAFT by default
+	GeneratedBy   uuid.UUID
+}
+
+func NewKitchenOrderService(
+	repo *repository.KitchenOrderRepository,
+	reservationRepo *repository.ReservationRepository,
+	customerRepo *repository.CustomerRepository,
+	catalogRepo *repository.CatalogRepository,
+	settingsRepo *repository.SettingsRepository,
+	folioService *FolioService,
+	billService *BillService,
+) *KitchenOrderService {
+	return &KitchenOrderService{
+		repo:            repo,
+		reservationRepo: reservationRepo,
+		customerRepo:    customerRepo,
+		catalogRepo:     catalogRepo,
+		settingsRepo:    settingsRepo,
+		folioService:    folioService,
+		billService:     billService,
+	}
+}
+
+func (s *KitchenOrderService) GetAll(status models.KitchenOrderStatus) ([]models.KitchenOrder, error) {
+	return s.repo.FindAll(status)
+}
+
+func (s *KitchenOrderService) GetByID(id uuid.UUID) (*models.KitchenOrder, error) {
+	order, err := s.repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrKitchenOrderNotFound
+	}
+	return order, err
+}
+
+// CreateOrder opens an order for a table or for a room. A room order is
+// tied to the reservation of the guest checked in to the room, whose folio
+// it is charged to.
+func (s *KitchenOrderService) CreateOrder(order *models.KitchenOrder) error {
+	order.TableNumber = strings.TrimSpace(order.TableNumber)
+	if (order.TableNumber == "") == (order.RoomID == nil) {
+		return ErrInvalidOrderTarget
+	}
+
+	order.ReservationID = nil
+	if order.RoomID != nil {
+		reservation, err := s.reservationRepo.FindInHouseByRoomID(*order.RoomID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoomNotInHouse
+		}
+		if err != nil {
+			return err
+		}
+		order.ReservationID = &reservation.ID
+	}
+
+	order.Status = models.KitchenOrderStatusOpen
+	order.BillID = nil
+	order.KOTCount = 0
+	return s.repo.Create(order)
+}
+
+// AddItem adds a food or beverage item from the catalog to an open order,
+// at the item's current price. It goes to the kitchen on the next KOT.
+func (s *KitchenOrderService) AddItem(orderID uuid.UUID, item *models.KitchenOrderItem) error {
+	if item.Quantity == 0 {
+		item.Quantity = 1
+	}
+	if item.Quantity < 0 {
+		return ErrInvalidOrderQuantity
+	}
+	catalogItem, err := activeCatalogItem(s.catalogRepo, item.CatalogItemID)
+	if err != nil {
+		return err
+	}
+	if catalogItem.Category.BillType() != models.BillTypeFood {
+		return ErrNotKitchenItem
+	}
+
+	item.OrderID = orderID
+	item.Description = catalogItem.Name
+	item.HSNSACCode = catalogItem.HSNSACCode
+	item.UnitPrice = catalogItem.DefaultPrice
+	item.TaxRate = catalogItem.TaxRate
+	item.Note = strings.TrimSpace(item.Note)
+	item.KOTNumber = 0
+
+	// Checked and added together, so an item can't land on an order that is
+	// being closed
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		orders := s.repo.WithTx(tx)
+		if _, err := findOpenOrder(orders, orderID); err != nil {
+			return err
+		}
+		return orders.CreateItem(item)
+	})
+}
+
+// RemoveItem takes an item off an open order before it has been sent to the
+// kitchen
+func (s *KitchenOrderService) RemoveItem(orderID, itemID uuid.UUID) error {
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		orders := s.repo.WithTx(tx)
+		order, err := findOpenOrder(orders, orderID)
+		if err != nil {
+			return err
+		}
+		for _, item := range order.Items {
+			if item.ID != itemID {
+				continue
+			}
+			if item.Sent() {
+				return ErrOrderItemSent
+			}
+			return orders.DeleteItem(itemID)
+		}
+		return ErrOrderItemNotFound
+	})
+}
+
+// PendingKOT renders the next KOT of an order, with the items not yet sent
+// to the kitchen, without sending it. A paper width of 0 uses the width
+// configured in settings.
+func (s *KitchenOrderService) PendingKOT(orderID uuid.UUID, paperWidth int) ([]byte, error) {
+	ticket, _, _, err := s.nextKOT(orderID, paperWidth)
+	return ticket, err
+}
+
+// SendKOT prints the next KOT of an order on the kitchen printer and marks
+// its items as sent. The kitchen printer defaults to the receipt printer.
+func (s *KitchenOrderService) SendKOT(orderID uuid.UUID, paperWidth int) (*models.KitchenOrder, error) {
+	ticket, order, items, err := s.nextKOT(orderID, paperWidth)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.settingsRepo.Get()
+	if err != nil {
+		return nil, ErrPrinterNotConfigured
+	}
+	address := settings.KitchenPrinterAddress
+	if address == "" {
+		address = settings.ReceiptPrinterAddress
+	}
+	if address == "" {
+		return nil, ErrPrinterNotConfigured
+	}
+	if err := escpos.Send(address, ticket, printTimeout); err != nil {
+		return nil, fmt.Errorf("%w at %s: %v", ErrPrintFailed, address, err)
+	}
+
+	itemIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		itemIDs[i] = item.ID
+	}
+	if err := s.repo.MarkItemsSent(order.ID, itemIDs, order.KOTCount+1); err != nil {
+		return nil, err
+	}
+	return s.GetByID(order.ID)
+}
+
+// nextKOT renders the ticket for an open order's unsent items, returning
+// the order and those items with it
+func (s *KitchenOrderService) nextKOT(orderID uuid.UUID, paperWidth int) ([]byte, *models.KitchenOrder, []models.KitchenOrderItem, error) {
+	order, err := s.openOrder(orderID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var pending []models.KitchenOrderItem
+	for _, item := range order.Items {
+		if !item.Sent() {
+			pending = append(pending, item)
+		}
+	}
+	if len(pending) == 0 {
+		return nil, nil, nil, ErrNothingToSend
+	}
+
+	settings, err := s.settingsRepo.Get()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load lodge settings: %w", err)
+	}
+	paperWidth, err = resolvePaperWidth(paperWidth, settings.ReceiptPaperWidth)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ticket := invoice.KitchenTicket(order, pending, order.KOTCount+1, time.Now(), paperWidth)
+	return ticket, order, pending, nil
+}
+
+// CloseOrder charges an order and closes it. Orders charged to a
+// reservation are posted to its folio as FOOD charges; the rest become a
+// FOOD bill through BillService. Either way the items are charged at the
+// prices they were ordered at, one line per item and price with the
+// quantities ordered added up, even if an item has since left the catalog.
+func (s *KitchenOrderService) CloseOrder(orderID uuid.UUID, opts CloseOrderOptions) (*models.KitchenOrder, error) {
+	order, err := s.openOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(order.Items) == 0 {
+		return nil, ErrKitchenOrderEmpty
+	}
+
+	reservationID := order.ReservationID
+	if reservationID == nil {
+		reservationID = opts.ReservationID
+	}
+	if reservationID == nil {
+		if opts.CustomerID == nil {
+			return nil, ErrOrderCustomerRequired
+		}
+		if _, err := s.customerRepo.FindByID(*opts.CustomerID); err != nil {
+			return nil, ErrCustomerNotFound
+		}
+	}
+
+	// The order is closed, charged and linked to the charge in one
+	// transaction, so it is either charged exactly once or left open
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		orders := s.repo.WithTx(tx)
+		claimed, err := orders.ChangeStatus(orderID, models.KitchenOrderStatusOpen, models.KitchenOrderStatusClosed)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return ErrKitchenOrderNotOpen
+		}
+		// Price the items as they stand now the order is claimed, in case
+		// one was added since it was loaded
+		order, err := orders.FindByID(orderID)
+		if err != nil {
+			return err
+		}
+		if len(order.Items) == 0 {
+			return ErrKitchenOrderEmpty
+		}
+
+		var billID *uuid.UUID
+		if reservationID != nil {
+			err = s.postToFolio(tx, *reservationID, order, opts.GeneratedBy)
+		} else {
+			billID, err = s.billOrder(tx, *opts.CustomerID, order, opts)
+		}
+		if err != nil {
+			return err
+		}
+		return orders.SetSettlement(orderID, reservationID, billID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetByID(orderID)
+}
+
+// CancelOrder closes an order without charging it
+func (s *KitchenOrderService) CancelOrder(orderID uuid.UUID) (*models.KitchenOrder, error) {
+	if _, err := s.GetByID(orderID); err != nil {
+		return nil, err
+	}
+	cancelled, err := s.repo.ChangeStatus(orderID, models.KitchenOrderStatusOpen, models.KitchenOrderStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, ErrKitchenOrderNotOpen
+	}
+	return s.GetByID(orderID)
+}
+
+func (s *KitchenOrderService) postToFolio(tx *gorm.DB, reservationID uuid.UUID, order *models.KitchenOrder, postedBy uuid.UUID) error {
+	var charges []*models.FolioCharge
+	for _, line := range orderLines(order) {
+		charges = append(charges, &models.FolioCharge{
+			ID:            uuid.New(),
+			BillType:      models.BillTypeFood,
+			Description:   line.Description,
+			HSNSACCode:    line.HSNSACCode,
+			Quantity:      line.Quantity,
+			UnitPrice:     line.UnitPrice,
+			TaxRate:       line.TaxRate,
+			CatalogItemID: &line.CatalogItemID,
+			PostedBy:      postedBy,
+		})
+	}
+	return s.folioService.PostPricedChargesTx(tx, reservationID, charges)
+}
+
+func (s *KitchenOrderService) billOrder(tx *gorm.DB, customerID uuid.UUID, order *models.KitchenOrder, opts CloseOrderOptions) (*uuid.UUID, error) {
+	var lineItems []models.BillLineItem
+	for _, line := range orderLines(order) {
+		lineItems = append(lineItems, models.BillLineItem{
+			ItemType:      models.LineItemTypeOther,
+			Description:   line.Description,
+			HSNSACCode:    line.HSNSACCode,
+			Quantity:      line.Quantity,
+			UnitPrice:     line.UnitPrice,
+			TaxRate:       line.TaxRate,
+			CatalogItemID: &line.CatalogItemID,
+		})
+	}
+
+	bill := &models.Bill{
+		ID:            uuid.New(),
+		CustomerID:    customerID,
+		BillType:      models.BillTypeFood,
+		BillDate:      formatDate(time.Now()),
+		IsGSTBill:     opts.IsGSTBill,
+		PlaceOfSupply: opts.PlaceOfSupply,
+		Status:        opts.Status,
+		GeneratedBy:   opts.GeneratedBy,
+	}
+	if err := s.billService.CreatePricedBillTx(tx, bill, lineItems); err != nil {
+		return nil, err
+	}
+	return &bill.ID, nil
+}
+
+// orderLines adds up the quantities of an order's items ordered at the same
+// price, in the order each was first ordered. The notes for the kitchen are
+// dropped.
+func orderLines(order *models.KitchenOrder) []models.KitchenOrderItem {
+	type priced struct {
+		catalogItemID uuid.UUID
+		unitPrice     models.Money
+		taxRate       float64
+	}
+	var lines []models.KitchenOrderItem
+	index := make(map[priced]int)
+	for _, item := range order.Items {
+		key := priced{item.CatalogItemID, item.UnitPrice, item.TaxRate}
+		if i, ok := index[key]; ok {
+			lines[i].Quantity += item.Quantity
+			continue
+		}
+		index[key] = len(lines)
+		item.Note = ""
+		lines = append(lines, item)
+	}
+	return lines
+}
+
+func (s *KitchenOrderService) openOrder(id uuid.UUID) (*models.KitchenOrder, error) {
+	return findOpenOrder(s.repo, id)
+}
+
+// findOpenOrder loads an order with its items through orders, which may be
+// bound to a transaction, and checks that it is still open
+func findOpenOrder(orders *repository.KitchenOrderRepository, id uuid.UUID) (*models.KitchenOrder, error) {
+	order, err := orders.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrKitchenOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.KitchenOrderStatusOpen {
+		return nil, ErrKitchenOrderNotOpen
+	}
+	return order, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPaymentMethodNotFound = errors.New("payment method not found")
+	ErrPaymentMethodRequired = errors.New("payment method name is required")
+	ErrPaymentMethodExists   = errors.New("a payment method with that name already exists")
+	ErrPaymentMethodRenamed  = errors.New("payment methods can't be renamed; deactivate the method and add a new one")
+	ErrUnknownPaymentMethod  = errors.New("unknown or inactive payment method")
+	ErrReferenceRequired     = errors.New("a reference number is required for this payment method")
+)
+
+type PaymentMethodService struct {
+	repo *repository.PaymentMethodRepository
+}
+
+func NewPaymentMethodService(repo *repository.PaymentMethodRepository) *PaymentMethodService {
+	return &PaymentMethodService{repo: repo}
+}
+
+func (s *PaymentMethodService) GetAll(includeInactive bool) ([]models.PaymentMethodConfig, error) {
+	return s.repo.FindAll(includeInactive)
+}
+
+// Create adds an active payment method. Names must be unique regardless of
+// case.
+func (s *PaymentMethodService) Create(method *models.PaymentMethodConfig) error {
+	method.Name = models.PaymentMethod(strings.TrimSpace(string(method.Name)))
+	if method.Name == "" {
+		return ErrPaymentMethodRequired
+	}
+	if _, err := s.repo.FindByName(string(method.Name)); err == nil {
+		return ErrPaymentMethodExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	method.IsActive = true
+	return s.repo.Create(method)
+}
+
+// Update changes whether a method needs a reference, whether it is active
+// and where it sorts. The name can't change.
+func (s *PaymentMethodService) Update(method *models.PaymentMethodConfig) error {
+	existing, err := s.repo.FindByID(method.ID)
+	if err != nil {
+		return ErrPaymentMethodNotFound
+	}
+	name := strings.TrimSpace(string(method.Name))
+	if name != "" && name != string(existing.Name) {
+		return ErrPaymentMethodRenamed
+	}
+
+	method.Name = existing.Name
+	method.CreatedAt = existing.CreatedAt
+	return s.repo.Update(method)
+}
+
+// resolvePaymentMethod finds the active payment method matching name in any
+// case, so "upi" and "UPI" are recorded the same way
+func resolvePaymentMethod(repo *repository.PaymentMethodRepository, name models.PaymentMethod) (*models.PaymentMethodConfig, error) {
+	method, err := repo.FindByName(string(name))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownPaymentMethod
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !method.IsActive {
+		return nil, ErrUnknownPaymentMethod
+	}
+	return method, nil
+}
@@ -1,54 +1,469 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 	"trinity-lodge/internal/models"
 	"trinity-lodge/internal/repository"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPaymentAmount = errors.New("payment amount must be greater than zero")
+	ErrBillNotPayable       = errors.New("payments can only be taken on finalized invoices and debit notes")
+	ErrOverpayment          = errors.New("payment exceeds the balance due")
+	ErrNoPaymentLegs        = errors.New("at least one payment is required")
+
+	ErrCustomerPaymentNotFound  = errors.New("customer payment not found")
+	ErrBillNotForCustomer       = errors.New("bill belongs to a different customer")
+	ErrDuplicateAllocation      = errors.New("a bill can only be allocated once per payment")
+	ErrAllocationExceedsPayment = errors.New("allocations exceed the amount paid")
+	ErrUnallocatedAmount        = errors.New("payment is more than its allocations")
+	ErrNoOutstandingBills       = errors.New("customer has no outstanding bills")
+	ErrInvalidPaymentDate       = errors.New("payment date must be a YYYY-MM-DD date")
+
+	ErrInvalidRefundType    = errors.New("refund type must be REFUND or REVERSAL")
+	ErrRefundReasonRequired = errors.New("a reason is required to refund or reverse a payment")
+	ErrRefundExceedsPayment = errors.New("refund exceeds what is left of the payment")
+	ErrPaymentFullyRefunded = errors.New("payment has already been refunded or reversed in full")
+	ErrInvalidRefundDate    = errors.New("refund date must be a YYYY-MM-DD date")
 )
 
 type PaymentService struct {
-	repo     *repository.PaymentRepository
-	billRepo *repository.BillRepository
+	repo       *repository.PaymentRepository
+	billRepo   *repository.BillRepository
+	creditRepo *repository.CustomerCreditRepository
+	methodRepo *repository.PaymentMethodRepository
 }
 
-func NewPaymentService(repo *repository.PaymentRepository, billRepo *repository.BillRepository) *PaymentService {
+func NewPaymentService(repo *repository.PaymentRepository, billRepo *repository.BillRepository, creditRepo *repository.CustomerCreditRepository, methodRepo *repository.PaymentMethodRepository) *PaymentService {
 	return &PaymentService{
-		repo:     repo,
-		billRepo: billRepo,
+		repo:       repo,
+		billRepo:   billRepo,
+		creditRepo: creditRepo,
+		methodRepo: methodRepo,
+	}
+}
+
+// PaymentOptions controls how a payment is applied to its bill
+type PaymentOptions struct {
+	// AllowOverpayment puts whatever exceeds the balance due on the
+	// customer's credit account instead of rejecting the payment
+	AllowOverpayment bool
+}
+
+// CreatePayment records a payment on a finalized bill and, in the same
+// transaction, updates the bill's amount paid, balance due and status.
+// The method must be one of the active payment methods, and methods that
+// require one need a reference number. Payments larger than the balance due
+// are rejected with ErrOverpayment unless opts allow them, in which case the
+// bill is paid in full and the excess is credited to the customer.
+func (s *PaymentService) CreatePayment(payment *models.Payment, opts PaymentOptions) error {
+	return s.CreateSplitPayment(payment.BillID, []*models.Payment{payment}, opts)
+}
+
+// CreateSplitPayment records several payments on one bill in a single
+// transaction, e.g. part in cash and part by UPI. Every leg is checked as in
+// CreatePayment before anything is written, and the bill's balance and
+// status are settled once for the lot, so either all legs are recorded or
+// none are. The legs are applied in order; with opts allowing an
+// overpayment, whatever the bill no longer needs is credited to the customer
+// from the legs that overshoot.
+func (s *PaymentService) CreateSplitPayment(billID uuid.UUID, legs []*models.Payment, opts PaymentOptions) error {
+	if len(legs) == 0 {
+		return ErrNoPaymentLegs
+	}
+	var total models.Money
+	for i, payment := range legs {
+		if err := s.checkPayment(payment); err != nil {
+			if len(legs) > 1 {
+				return fmt.Errorf("payment %d: %w", i+1, err)
+			}
+			return err
+		}
+		payment.BillID = billID
+		total += payment.Amount
 	}
+
+	return s.billRepo.Transaction(func(tx *gorm.DB) error {
+		bills := s.billRepo.WithTx(tx)
+		payments := s.repo.WithTx(tx)
+
+		bill, err := bills.FindByID(billID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrBillNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if bill.Status == models.BillStatusDraft || !isPayable(bill) {
+			return ErrBillNotPayable
+		}
+		if bill.BalanceDue <= 0 {
+			return ErrNothingDue
+		}
+		if total > bill.BalanceDue && !opts.AllowOverpayment {
+			return fmt.Errorf("%w of %s", ErrOverpayment, bill.BalanceDue)
+		}
+
+		due := bill.BalanceDue
+		for _, payment := range legs {
+			excess := payment.Amount - max(due, 0)
+			if excess > 0 {
+				payment.Amount -= excess
+				payment.ExcessCredited = excess
+			}
+			due -= payment.Amount
+
+			if err := payments.Create(payment); err != nil {
+				return err
+			}
+
+			if excess > 0 {
+				credit := &models.CustomerCredit{
+					CustomerID:  bill.CustomerID,
+					Amount:      excess,
+					BillID:      &bill.ID,
+					PaymentID:   &payment.ID,
+					Description: "Overpayment on " + bill.InvoiceNumber,
+				}
+				if err := s.creditRepo.WithTx(tx).Create(credit); err != nil {
+					return err
+				}
+			}
+		}
+
+		_, err = refreshSettlement(bills, payments, bill.ID)
+		return err
+	})
 }
 
-func (s *PaymentService) CreatePayment(payment *models.Payment) error {
-	err := s.repo.Create(payment)
+// checkPayment validates a payment before it is applied to a bill, putting
+// its method in canonical form. The payment date defaults to today.
+func (s *PaymentService) checkPayment(payment *models.Payment) error {
+	if payment.Amount <= 0 {
+		return ErrInvalidPaymentAmount
+	}
+
+	if payment.PaymentDate == "" {
+		payment.PaymentDate = formatDate(time.Now())
+	} else if _, err := parseDate(payment.PaymentDate); err != nil {
+		return ErrInvalidPaymentDate
+	}
+
+	method, err := resolvePaymentMethod(s.methodRepo, payment.PaymentMethod)
 	if err != nil {
 		return err
 	}
+	payment.PaymentMethod = method.Name
+	payment.ReferenceNumber = strings.TrimSpace(payment.ReferenceNumber)
+	if method.RequiresReference && payment.ReferenceNumber == "" {
+		return ErrReferenceRequired
+	}
+	return nil
+}
+
+// RefundOptions describes a refund or reversal of a payment
+type RefundOptions struct {
+	Type          models.RefundType
+	Amount        models.Money         // refunds only; a reversal takes back whatever is left
+	PaymentMethod models.PaymentMethod // defaults to the method of the payment
+	RefundDate    string               // defaults to today
+	Reason        string
+	CreatedBy     uuid.UUID
+}
+
+// RefundPayment refunds or reverses a payment made on a bill. The bill's
+// amount paid drops by the refunded amount and its status reopens if a
+// balance is due again. Reversing a payment also takes back any excess it
+// put on the customer's credit account, since that money was never received.
+func (s *PaymentService) RefundPayment(billID, paymentID uuid.UUID, opts RefundOptions) (*models.PaymentRefund, error) {
+	if opts.Type != models.RefundTypeRefund && opts.Type != models.RefundTypeReversal {
+		return nil, ErrInvalidRefundType
+	}
+	if opts.Reason == "" {
+		return nil, ErrRefundReasonRequired
+	}
+	if opts.Type == models.RefundTypeRefund && opts.Amount <= 0 {
+		return nil, ErrInvalidPaymentAmount
+	}
+	refundDate := opts.RefundDate
+	if refundDate == "" {
+		refundDate = formatDate(time.Now())
+	} else if _, err := parseDate(refundDate); err != nil {
+		return nil, ErrInvalidRefundDate
+	}
+
+	var refund *models.PaymentRefund
+	err := s.billRepo.Transaction(func(tx *gorm.DB) error {
+		payments := s.repo.WithTx(tx)
+
+		payment, err := payments.FindByID(paymentID)
+		if err != nil || payment.BillID != billID {
+			return ErrPaymentNotFound
+		}
+
+		remaining := payment.Amount - payment.Refunded()
+		if remaining <= 0 {
+			return ErrPaymentFullyRefunded
+		}
+		amount := opts.Amount
+		if opts.Type == models.RefundTypeReversal {
+			amount = remaining
+		} else if amount > remaining {
+			return fmt.Errorf("%w (%s left)", ErrRefundExceedsPayment, remaining)
+		}
 
-	// Get bill to check if fully paid
-	bill, err := s.billRepo.FindByID(payment.BillID)
+		method := payment.PaymentMethod
+		if opts.PaymentMethod != "" {
+			configured, err := resolvePaymentMethod(s.methodRepo.WithTx(tx), opts.PaymentMethod)
+			if err != nil {
+				return err
+			}
+			method = configured.Name
+		}
+
+		refund = &models.PaymentRefund{
+			PaymentID:     payment.ID,
+			BillID:        payment.BillID,
+			Type:          opts.Type,
+			Amount:        amount,
+			PaymentMethod: method,
+			RefundDate:    refundDate,
+			Reason:        opts.Reason,
+			CreatedBy:     opts.CreatedBy,
+		}
+		if err := payments.CreateRefund(refund); err != nil {
+			return err
+		}
+
+		bills := s.billRepo.WithTx(tx)
+		bill, err := refreshSettlement(bills, payments, payment.BillID)
+		if err != nil {
+			return err
+		}
+
+		if opts.Type == models.RefundTypeReversal && payment.ExcessCredited > 0 {
+			credit := &models.CustomerCredit{
+				CustomerID:  bill.CustomerID,
+				Amount:      -payment.ExcessCredited,
+				BillID:      &bill.ID,
+				PaymentID:   &payment.ID,
+				Description: "Reversal of overpayment on " + bill.InvoiceNumber,
+			}
+			return s.creditRepo.WithTx(tx).Create(credit)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil // Payment created successfully, just can't update bill status
+		return nil, err
 	}
+	return refund, nil
+}
 
-	// Get all payments for this bill
-	payments, err := s.repo.FindByBillID(payment.BillID)
+// Allocation is the part of a customer payment applied to one bill
+type Allocation struct {
+	BillID uuid.UUID
+	Amount models.Money
+}
+
+// CreateCustomerPayment records one sum received from a customer against
+// several of their bills. Each allocation becomes a payment on its bill and
+// the bills' balances and statuses are updated, all in one transaction.
+// Allocations must be for the customer's own finalized bills and may not
+// exceed any bill's balance. Without allocations the sum is applied to the
+// customer's outstanding bills oldest first. Any part of the sum left
+// unallocated is rejected with ErrUnallocatedAmount unless opts allow an
+// overpayment, in which case it is credited to the customer.
+func (s *PaymentService) CreateCustomerPayment(payment *models.CustomerPayment, allocations []Allocation, opts PaymentOptions) error {
+	if payment.Amount <= 0 {
+		return ErrInvalidPaymentAmount
+	}
+	method, err := resolvePaymentMethod(s.methodRepo, payment.PaymentMethod)
 	if err != nil {
+		return err
+	}
+	payment.PaymentMethod = method.Name
+	payment.ReferenceNumber = strings.TrimSpace(payment.ReferenceNumber)
+	if method.RequiresReference && payment.ReferenceNumber == "" {
+		return ErrReferenceRequired
+	}
+	if payment.PaymentDate == "" {
+		payment.PaymentDate = formatDate(time.Now())
+	} else if _, err := parseDate(payment.PaymentDate); err != nil {
+		return ErrInvalidPaymentDate
+	}
+
+	var allocated models.Money
+	seen := make(map[uuid.UUID]bool)
+	for _, a := range allocations {
+		if a.Amount <= 0 {
+			return ErrInvalidPaymentAmount
+		}
+		if seen[a.BillID] {
+			return ErrDuplicateAllocation
+		}
+		seen[a.BillID] = true
+		allocated += a.Amount
+	}
+	if allocated > payment.Amount {
+		return fmt.Errorf("%w of %s", ErrAllocationExceedsPayment, payment.Amount)
+	}
+
+	return s.billRepo.Transaction(func(tx *gorm.DB) error {
+		bills := s.billRepo.WithTx(tx)
+		payments := s.repo.WithTx(tx)
+
+		if len(allocations) == 0 {
+			allocations, err = s.allocateOldestFirst(bills, payment.CustomerID, payment.Amount)
+			if err != nil {
+				return err
+			}
+			allocated = 0
+			for _, a := range allocations {
+				allocated += a.Amount
+			}
+		}
+
+		excess := payment.Amount - allocated
+		if excess > 0 && !opts.AllowOverpayment {
+			return fmt.Errorf("%w by %s", ErrUnallocatedAmount, excess)
+		}
+		payment.ExcessCredited = excess
+
+		if err := payments.CreateCustomerPayment(payment); err != nil {
+			return err
+		}
+
+		payment.Allocations = make([]models.Payment, 0, len(allocations))
+		for _, a := range allocations {
+			bill, err := bills.FindByID(a.BillID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBillNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if bill.CustomerID != payment.CustomerID {
+				return fmt.Errorf("%w: %s", ErrBillNotForCustomer, bill.InvoiceNumber)
+			}
+			if bill.Status == models.BillStatusDraft || !isPayable(bill) {
+				return fmt.Errorf("%w: %s", ErrBillNotPayable, bill.InvoiceNumber)
+			}
+			if a.Amount > bill.BalanceDue {
+				return fmt.Errorf("%w of %s on %s", ErrOverpayment, bill.BalanceDue, bill.InvoiceNumber)
+			}
+
+			share := models.Payment{
+				BillID:            bill.ID,
+				Amount:            a.Amount,
+				PaymentMethod:     payment.PaymentMethod,
+				PaymentDate:       payment.PaymentDate,
+				ReferenceNumber:   payment.ReferenceNumber,
+				Note:              payment.Note,
+				CustomerPaymentID: &payment.ID,
+			}
+			if err := payments.Create(&share); err != nil {
+				return err
+			}
+			settled, err := refreshSettlement(bills, payments, bill.ID)
+			if err != nil {
+				return err
+			}
+			share.Bill = settled
+			payment.Allocations = append(payment.Allocations, share)
+		}
+
+		if excess > 0 {
+			credit := &models.CustomerCredit{
+				CustomerID:  payment.CustomerID,
+				Amount:      excess,
+				Description: fmt.Sprintf("Unallocated part of %s payment on %s", payment.PaymentMethod, payment.PaymentDate),
+			}
+			if err := s.creditRepo.WithTx(tx).Create(credit); err != nil {
+				return err
+			}
+		}
 		return nil
+	})
+}
+
+// allocateOldestFirst spreads amount over the customer's outstanding bills,
+// oldest bill first, until either runs out
+func (s *PaymentService) allocateOldestFirst(bills *repository.BillRepository, customerID uuid.UUID, amount models.Money) ([]Allocation, error) {
+	customerBills, err := bills.FindByCustomerID(customerID)
+	if err != nil {
+		return nil, err
 	}
+	sort.SliceStable(customerBills, func(i, j int) bool {
+		a, b := customerBills[i], customerBills[j]
+		if a.BillDate != b.BillDate {
+			return a.BillDate < b.BillDate
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
 
-	// Calculate total paid
-	var totalPaid float64
-	for _, p := range payments {
-		totalPaid += p.Amount
+	var allocations []Allocation
+	for _, bill := range customerBills {
+		if amount <= 0 {
+			break
+		}
+		if bill.Status == models.BillStatusDraft || !isPayable(&bill) || bill.BalanceDue <= 0 {
+			continue
+		}
+		share := min(amount, bill.BalanceDue)
+		allocations = append(allocations, Allocation{BillID: bill.ID, Amount: share})
+		amount -= share
 	}
+	if len(allocations) == 0 {
+		return nil, ErrNoOutstandingBills
+	}
+	return allocations, nil
+}
 
-	// Update bill status if fully paid
-	if totalPaid >= bill.TotalAmount {
-		s.billRepo.UpdateStatus(payment.BillID, models.BillStatusPaid)
+func (s *PaymentService) GetCustomerPayment(id uuid.UUID) (*models.CustomerPayment, error) {
+	payment, err := s.repo.FindCustomerPayment(id)
+	if err != nil {
+		return nil, ErrCustomerPaymentNotFound
 	}
+	return payment, nil
+}
 
-	return nil
+func (s *PaymentService) GetCustomerPayments(customerID uuid.UUID) ([]models.CustomerPayment, error) {
+	return s.repo.FindCustomerPaymentsByCustomerID(customerID)
+}
+
+// PaymentSearch filters payments. Empty fields match everything; Reference
+// matches any part of the reference number, ignoring case.
+type PaymentSearch struct {
+	Reference string
+	Method    models.PaymentMethod
+	From      string
+	To        string
+}
+
+// SearchPayments finds payments by reference number, method and date, with
+// their bills and customers, newest first
+func (s *PaymentService) SearchPayments(search PaymentSearch) ([]models.Payment, error) {
+	for _, date := range []string{search.From, search.To} {
+		if date == "" {
+			continue
+		}
+		if _, err := parseDate(date); err != nil {
+			return nil, ErrInvalidReportPeriod
+		}
+	}
+	return s.repo.Search(repository.PaymentFilter{
+		Reference: strings.TrimSpace(search.Reference),
+		Method:    search.Method,
+		From:      search.From,
+		To:        search.To,
+	})
 }
 
 func (s *PaymentService) GetPaymentsByBillID(billID uuid.UUID) ([]models.Payment, error) {
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRatePlanNotFound    = errors.New("rate plan not found")
+	ErrRoomTypeNotFound    = errors.New("room type not found")
+	ErrInvalidRatePlan     = errors.New("invalid rate plan")
+	ErrUnknownRatePlanCode = errors.New("no rate plan of this room type has that code")
+	ErrInvalidQuoteDates   = errors.New("from and to must be YYYY-MM-DD dates")
+	ErrInvalidOccupancy    = errors.New("occupancy must be at least 1")
+)
+
+// standardRateName labels the nights charged at a room type's default rate
+const standardRateName = "Standard"
+
+// NightRate is the price of one night of a stay and the plan it came from
+type NightRate struct {
+	Date         string       `json:"date"`
+	Rate         models.Money `json:"rate"`
+	RatePlanID   *uuid.UUID   `json:"rate_plan_id,omitempty"`
+	RatePlanName string       `json:"rate_plan_name"`
+}
+
+// Quote is the nightly breakdown of a stay in a room type, before tax
+type Quote struct {
+	RoomTypeID   uuid.UUID    `json:"room_type_id"`
+	RoomTypeName string       `json:"room_type_name"`
+	CheckIn      string       `json:"check_in"`
+	CheckOut     string       `json:"check_out"`
+	Occupancy    int          `json:"occupancy"`
+	RatePlanCode string       `json:"rate_plan_code,omitempty"`
+	Nights       []NightRate  `json:"nights"`
+	Total        models.Money `json:"total"`
+}
+
+// PricingService keeps the rate plans of each room type and quotes stays
+// from them
+type PricingService struct {
+	repo     *repository.RatePlanRepository
+	roomRepo *repository.RoomRepository
+}
+
+func NewPricingService(repo *repository.RatePlanRepository, roomRepo *repository.RoomRepository) *PricingService {
+	return &PricingService{repo: repo, roomRepo: roomRepo}
+}
+
+func (s *PricingService) GetRatePlans(roomTypeID *uuid.UUID) ([]models.RatePlan, error) {
+	return s.repo.FindAll(roomTypeID)
+}
+
+func (s *PricingService) GetRatePlan(id uuid.UUID) (*models.RatePlan, error) {
+	plan, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, ErrRatePlanNotFound
+	}
+	return plan, nil
+}
+
+func (s *PricingService) CreateRatePlan(plan *models.RatePlan) error {
+	if err := s.validateRatePlan(plan); err != nil {
+		return err
+	}
+	return s.repo.Create(plan)
+}
+
+// UpdateRatePlan changes a plan and replaces its prices. Bills already
+// generated keep the rates they were billed at.
+func (s *PricingService) UpdateRatePlan(plan *models.RatePlan) error {
+	existing, err := s.repo.FindByID(plan.ID)
+	if err != nil {
+		return ErrRatePlanNotFound
+	}
+	if err := s.validateRatePlan(plan); err != nil {
+		return err
+	}
+	plan.CreatedAt = existing.CreatedAt
+	return s.repo.Update(plan)
+}
+
+func (s *PricingService) DeleteRatePlan(id uuid.UUID) error {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return ErrRatePlanNotFound
+	}
+	return s.repo.Delete(id)
+}
+
+// Quote prices a stay in a room type night by night, from check-in up to
+// the checkout date. Each night is charged at the highest priority plan
+// that applies to it, or at the room type's default rate when none does.
+// Plans with a code only apply when code names them.
+func (s *PricingService) Quote(roomTypeID uuid.UUID, checkIn, checkOut string, occupancy int, code string) (*Quote, error) {
+	start, err := parseDate(checkIn)
+	if err != nil {
+		return nil, ErrInvalidQuoteDates
+	}
+	end, err := parseDate(checkOut)
+	if err != nil {
+		return nil, ErrInvalidQuoteDates
+	}
+	if !end.After(start) {
+		return nil, ErrInvalidStayDates
+	}
+	if occupancy == 0 {
+		occupancy = 1
+	}
+	if occupancy < 0 {
+		return nil, ErrInvalidOccupancy
+	}
+
+	roomType, err := s.roomRepo.FindRoomTypeByID(roomTypeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRoomTypeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	plans, err := s.repo.FindForRoomType(roomTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	code = normalizeRateCode(code)
+	if code != "" && !hasRateCode(plans, code) {
+		return nil, ErrUnknownRatePlanCode
+	}
+	return quoteStay(roomType, plans, start, end, occupancy, code), nil
+}
+
+// quoteStay prices each night from start up to end. plans must be those of
+// roomType, highest priority first.
+func quoteStay(roomType *models.RoomType, plans []models.RatePlan, start, end time.Time, occupancy int, code string) *Quote {
+	quote := &Quote{
+		RoomTypeID:   roomType.ID,
+		RoomTypeName: roomType.Name,
+		CheckIn:      formatDate(start),
+		CheckOut:     formatDate(end),
+		Occupancy:    occupancy,
+		RatePlanCode: code,
+	}
+	for night := start; night.Before(end); night = night.AddDate(0, 0, 1) {
+		rate := NightRate{Date: formatDate(night), Rate: roomType.DefaultRate, RatePlanName: standardRateName}
+		for i := range plans {
+			plan := &plans[i]
+			if plan.Code != "" && plan.Code != code {
+				continue
+			}
+			if !plan.AppliesOn(night) {
+				continue
+			}
+			price, ok := plan.PriceFor(occupancy)
+			if !ok {
+				continue
+			}
+			rate = NightRate{Date: formatDate(night), Rate: price, RatePlanID: &plan.ID, RatePlanName: plan.Name}
+			break
+		}
+		quote.Nights = append(quote.Nights, rate)
+		quote.Total += rate.Rate
+	}
+	return quote
+}
+
+func hasRateCode(plans []models.RatePlan, code string) bool {
+	for _, plan := range plans {
+		if plan.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeRateCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *PricingService) validateRatePlan(plan *models.RatePlan) error {
+	plan.Name = strings.TrimSpace(plan.Name)
+	plan.Code = normalizeRateCode(plan.Code)
+	if plan.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRatePlan)
+	}
+	if _, err := s.roomRepo.FindRoomTypeByID(plan.RoomTypeID); err != nil {
+		return fmt.Errorf("%w: room type not found", ErrInvalidRatePlan)
+	}
+
+	for _, date := range []*string{plan.ValidFrom, plan.ValidTo} {
+		if date != nil {
+			if _, err := parseDate(*date); err != nil {
+				return fmt.Errorf("%w: valid_from and valid_to must be YYYY-MM-DD dates", ErrInvalidRatePlan)
+			}
+		}
+	}
+	if plan.ValidFrom != nil && plan.ValidTo != nil && *plan.ValidTo < *plan.ValidFrom {
+		return fmt.Errorf("%w: valid_to is before valid_from", ErrInvalidRatePlan)
+	}
+
+	var days []string
+	for _, day := range strings.Split(plan.DaysOfWeek, ",") {
+		day = strings.ToUpper(strings.TrimSpace(day))
+		if day == "" {
+			continue
+		}
+		if !isWeekday(day) {
+			return fmt.Errorf("%w: days_of_week must list days as SUN, MON, TUE, WED, THU, FRI or SAT", ErrInvalidRatePlan)
+		}
+		days = append(days, day)
+	}
+	plan.DaysOfWeek = strings.Join(days, ",")
+
+	if len(plan.Prices) == 0 {
+		return fmt.Errorf("%w: at least one price is required", ErrInvalidRatePlan)
+	}
+	seen := make(map[int]bool)
+	for _, price := range plan.Prices {
+		if price.Occupancy < 1 || price.Rate < 0 {
+			return fmt.Errorf("%w: prices need an occupancy of at least 1 and a rate that isn't negative", ErrInvalidRatePlan)
+		}
+		if seen[price.Occupancy] {
+			return fmt.Errorf("%w: occupancy %d is priced twice", ErrInvalidRatePlan, price.Occupancy)
+		}
+		seen[price.Occupancy] = true
+	}
+	sort.Slice(plan.Prices, func(i, j int) bool { return plan.Prices[i].Occupancy < plan.Prices[j].Occupancy })
+	return nil
+}
+
+func isWeekday(day string) bool {
+	for _, d := range models.Weekdays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"trinity-lodge/internal/escpos"
+	"trinity-lodge/internal/invoice"
+	"trinity-lodge/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidPaperWidth    = errors.New("paper width must be 58 or 80 (mm)")
+	ErrPaymentNotFound      = errors.New("payment not found")
+	ErrPrinterNotConfigured = errors.New("no receipt printer address is configured in settings")
+	ErrPrintFailed          = errors.New("could not send the receipt to the printer")
+)
+
+// printTimeout bounds how long a print job waits on an unreachable printer
+const printTimeout = 5 * time.Second
+
+// ReceiptService renders bills and payments as ESC/POS thermal receipts and
+// sends them to the front desk printer
+type ReceiptService struct {
+	billService    *BillService
+	paymentService *PaymentService
+	settingsRepo   *repository.SettingsRepository
+}
+
+func NewReceiptService(billService *BillService, paymentService *PaymentService, settingsRepo *repository.SettingsRepository) *ReceiptService {
+	return &ReceiptService{
+		billService:    billService,
+		paymentService: paymentService,
+		settingsRepo:   settingsRepo,
+	}
+}
+
+// BillReceipt renders a bill for the given paper width in mm. A width of 0
+// uses the width configured in settings.
+func (s *ReceiptService) BillReceipt(billID uuid.UUID, paperWidth int) ([]byte, error) {
+	doc, err := s.billService.InvoiceDocument(billID)
+	if err != nil {
+		return nil, err
+	}
+	paperWidth, err = resolvePaperWidth(paperWidth, doc.Settings.ReceiptPaperWidth)
+	if err != nil {
+		return nil, err
+	}
+	return invoice.BillReceipt(*doc, paperWidth), nil
+}
+
+// PaymentReceipt renders an acknowledgement of one payment made on a bill
+func (s *ReceiptService) PaymentReceipt(billID, paymentID uuid.UUID, paperWidth int) ([]byte, error) {
+	doc, err := s.billService.InvoiceDocument(billID)
+	if err != nil {
+		return nil, err
+	}
+	paperWidth, err = resolvePaperWidth(paperWidth, doc.Settings.ReceiptPaperWidth)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.paymentService.GetPaymentsByBillID(billID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range payments {
+		if payments[i].ID == paymentID {
+			return invoice.PaymentReceipt(*doc, &payments[i], paperWidth), nil
+		}
+	}
+	return nil, ErrPaymentNotFound
+}
+
+// CustomerPaymentReceipt renders an acknowledgement of a payment that
+// settled several bills, with its allocation to each
+func (s *ReceiptService) CustomerPaymentReceipt(paymentID uuid.UUID, paperWidth int) ([]byte, error) {
+	payment, err := s.paymentService.GetCustomerPayment(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.settingsRepo.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lodge settings: %w", err)
+	}
+	paperWidth, err = resolvePaperWidth(paperWidth, settings.ReceiptPaperWidth)
+	if err != nil {
+		return nil, err
+	}
+	return invoice.CustomerPaymentReceipt(settings, payment, paperWidth), nil
+}
+
+// Print sends a rendered receipt to the network printer configured in
+// settings
+func (s *ReceiptService) Print(receipt []byte) error {
+	settings, err := s.settingsRepo.Get()
+	if err != nil || settings.ReceiptPrinterAddress == "" {
+		return ErrPrinterNotConfigured
+	}
+	if err := escpos.Send(settings.ReceiptPrinterAddress, receipt, printTimeout); err != nil {
+		return fmt.Errorf("%w at %s: %v", ErrPrintFailed, settings.ReceiptPrinterAddress, err)
+	}
+	return nil
+}
+
+func resolvePaperWidth(requested, configured int) (int, error) {
+	width := requested
+	if width == 0 {
+		width = configured
+	}
+	if width == 0 {
+		width = escpos.Paper80mm
+	}
+	if width != escpos.Paper58mm && width != escpos.Paper80mm {
+		return 0, ErrInvalidPaperWidth
+	}
+	return width, nil
+}
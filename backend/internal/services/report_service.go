@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/repository"
+)
+
+var ErrInvalidReportPeriod = errors.New("from and to must be YYYY-MM-DD dates with from on or before to")
+
+type ReportService struct {
+	billRepo        *repository.BillRepository
+	paymentRepo     *repository.PaymentRepository
+	reservationRepo *repository.ReservationRepository
+}
+
+func NewReportService(billRepo *repository.BillRepository, paymentRepo *repository.PaymentRepository, reservationRepo *repository.ReservationRepository) *ReportService {
+	return &ReportService{billRepo: billRepo, paymentRepo: paymentRepo, reservationRepo: reservationRepo}
+}
+
+// RevenueReport summarises invoiced revenue for a period. Credit notes are
+// subtracted and debit notes added; drafts and cancelled bills are left out.
+type RevenueReport struct {
+	From           string                           `json:"from"`
+	To             string                           `json:"to"`
+	InvoiceCount   int                              `json:"invoice_count"`
+	CancelledCount int                              `json:"cancelled_count"`
+	TaxableAmount  models.Money                     `json:"taxable_amount"`
+	CGSTAmount     models.Money                     `json:"cgst_amount"`
+	SGSTAmount     models.Money                     `json:"sgst_amount"`
+	IGSTAmount     models.Money                     `json:"igst_amount"`
+	TaxAmount      models.Money                     `json:"tax_amount"`
+	RoundOff       models.Money                     `json:"round_off"`
+	CreditNotes    models.Money                     `json:"credit_notes"`
+	DebitNotes     models.Money                     `json:"debit_notes"`
+	NetRevenue     models.Money                     `json:"net_revenue"`
+	ByBillType     map[models.BillType]models.Money `json:"by_bill_type"`
+}
+
+func (s *ReportService) Revenue(from, to string) (*RevenueReport, error) {
+	if err := validatePeriod(from, to); err != nil {
+		return nil, err
+	}
+
+	bills, err := s.billRepo.FindIssuedBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RevenueReport{
+		From:       from,
+		To:         to,
+		ByBillType: make(map[models.BillType]models.Money),
+	}
+
+	for _, bill := range bills {
+		if bill.Status == models.BillStatusCancelled {
+			report.CancelledCount++
+			continue
+		}
+
+		sign := models.Money(1)
+		switch bill.DocumentType {
+		case models.DocumentTypeCreditNote:
+			sign = -1
+			report.CreditNotes += bill.TotalAmount
+		case models.DocumentTypeDebitNote:
+			report.DebitNotes += bill.TotalAmount
+		default:
+			report.InvoiceCount++
+		}
+
+		report.TaxableAmount += sign * (bill.Subtotal - bill.DiscountAmount)
+		report.CGSTAmount += sign * bill.CGSTAmount
+		report.SGSTAmount += sign * bill.SGSTAmount
+		report.IGSTAmount += sign * bill.IGSTAmount
+		report.TaxAmount += sign * bill.TaxAmount
+		report.RoundOff += sign * bill.RoundOff
+		report.NetRevenue += sign * bill.TotalAmount
+		report.ByBillType[bill.BillType] += sign * bill.TotalAmount
+	}
+
+	return report, nil
+}
+
+// CollectionReport summarises money taken in and paid out over a period,
+// by payment date for payments and refund date for refunds and reversals.
+// Collected includes overpayments credited to customer accounts, including
+// the unallocated part of payments that settled several bills, and advance
+// deposits, which count when they are received rather than when they are
+// applied to a bill; unused deposits refunded at billing count as refunds.
+type CollectionReport struct {
+	From         string                                      `json:"from"`
+	To           string                                      `json:"to"`
+	PaymentCount int                                         `json:"payment_count"`
+	DepositCount int                                         `json:"deposit_count"`
+	Collected    models.Money                                `json:"collected"`
+	Advances     models.Money                                `json:"advance_deposits"`
+	Credited     models.Money                                `json:"credited_to_accounts"`
+	Refunds      models.Money                                `json:"refunds"`
+	Reversals    models.Money                                `json:"reversals"`
+	Net          models.Money                                `json:"net"`
+	ByMethod     map[models.PaymentMethod]*MethodCollections `json:"by_method"`
+}
+
+// MethodCollections is the part of a collection report for one payment method
+type MethodCollections struct {
+	Collected models.Money `json:"collected"`
+	Refunds   models.Money `json:"refunds"`
+	Reversals models.Money `json:"reversals"`
+	Net       models.Money `json:"net"`
+}
+
+func (s *ReportService) Collections(from, to string) (*CollectionReport, error) {
+	if err := validatePeriod(from, to); err != nil {
+		return nil, err
+	}
+
+	payments, err := s.paymentRepo.FindBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := s.paymentRepo.FindRefundsBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	customerPayments, err := s.paymentRepo.FindCustomerPaymentsBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	deposits, err := s.reservationRepo.FindDepositsBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	depositRefunds, err := s.reservationRepo.FindDepositRefundsBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CollectionReport{
+		From:     from,
+		To:       to,
+		ByMethod: make(map[models.PaymentMethod]*MethodCollections),
+	}
+	method := func(m models.PaymentMethod) *MethodCollections {
+		if report.ByMethod[m] == nil {
+			report.ByMethod[m] = &MethodCollections{}
+		}
+		return report.ByMethod[m]
+	}
+
+	for _, p := range payments {
+		if p.DepositID != nil {
+			// Counted as a deposit on the day it was received
+			continue
+		}
+		received := p.Amount + p.ExcessCredited
+		report.PaymentCount++
+		report.Collected += received
+		report.Credited += p.ExcessCredited
+		m := method(p.PaymentMethod)
+		m.Collected += received
+		m.Net += received
+	}
+
+	for _, p := range customerPayments {
+		// The allocations are counted as payments above
+		if p.ExcessCredited == 0 {
+			continue
+		}
+		report.Collected += p.ExcessCredited
+		report.Credited += p.ExcessCredited
+		m := method(p.PaymentMethod)
+		m.Collected += p.ExcessCredited
+		m.Net += p.ExcessCredited
+	}
+
+	for _, r := range refunds {
+		m := method(r.PaymentMethod)
+		switch r.Type {
+		case models.RefundTypeReversal:
+			report.Reversals += r.Amount
+			m.Reversals += r.Amount
+		default:
+			report.Refunds += r.Amount
+			m.Refunds += r.Amount
+		}
+		m.Net -= r.Amount
+	}
+
+	for _, d := range deposits {
+		report.DepositCount++
+		report.Collected += d.Amount
+		report.Advances += d.Amount
+		m := method(d.PaymentMethod)
+		m.Collected += d.Amount
+		m.Net += d.Amount
+	}
+
+	for _, d := range depositRefunds {
+		report.Refunds += d.RefundedAmount
+		m := method(d.PaymentMethod)
+		m.Refunds += d.RefundedAmount
+		m.Net -= d.RefundedAmount
+	}
+
+	report.Net = report.Collected - report.Refunds - report.Reversals
+	return report, nil
+}
+
+func validatePeriod(from, to string) error {
+	start, err := parseDate(from)
+	if err != nil {
+		return ErrInvalidReportPeriod
+	}
+	end, err := parseDate(to)
+	if err != nil || end.Before(start) {
+		return ErrInvalidReportPeriod
+	}
+	return nil
+}
@@ -2,22 +2,34 @@ package services
 
 import (
 	"errors"
+	"strings"
 	"time"
 	"trinity-lodge/internal/models"
 	"trinity-lodge/internal/repository"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+var ErrInvalidDepositDate = errors.New("deposit date must be a YYYY-MM-DD date")
+
 type ReservationService struct {
-	repo     *repository.ReservationRepository
-	roomRepo *repository.RoomRepository
+	repo         *repository.ReservationRepository
+	roomRepo     *repository.RoomRepository
+	billRepo     *repository.BillRepository
+	methodRepo   *repository.PaymentMethodRepository
+	ratePlanRepo *repository.RatePlanRepository
+	creditRepo   *repository.CustomerCreditRepository
 }
 
-func NewReservationService(repo *repository.ReservationRepository, roomRepo *repository.RoomRepository) *ReservationService {
+func NewReservationService(repo *repository.ReservationRepository, roomRepo *repository.RoomRepository, billRepo *repository.BillRepository, methodRepo *repository.PaymentMethodRepository, ratePlanRepo *repository.RatePlanRepository, creditRepo *repository.CustomerCreditRepository) *ReservationService {
 	return &ReservationService{
-		repo:     repo,
-		roomRepo: roomRepo,
+		repo:         repo,
+		roomRepo:     roomRepo,
+		billRepo:     billRepo,
+		methodRepo:   methodRepo,
+		ratePlanRepo: ratePlanRepo,
+		creditRepo:   creditRepo,
 	}
 }
 
@@ -28,6 +40,25 @@ func (s *ReservationService) CreateReservation(reservation *models.Reservation)
 		return err
 	}
 
+	if reservation.Occupancy == 0 {
+		reservation.Occupancy = 1
+	}
+	if reservation.Occupancy < 0 {
+		return ErrInvalidOccupancy
+	}
+	// A rate plan code has to name one of the room type's plans, or the stay
+	// would quietly be billed at the standard rates
+	reservation.RatePlanCode = normalizeRateCode(reservation.RatePlanCode)
+	if reservation.RatePlanCode != "" {
+		plans, err := s.ratePlanRepo.FindForRoomType(room.TypeID)
+		if err != nil {
+			return err
+		}
+		if !hasRateCode(plans, reservation.RatePlanCode) {
+			return ErrUnknownRatePlanCode
+		}
+	}
+
 	// Check for overlapping reservations
 	overlapping, err := s.repo.FindOverlappingReservations(
 		reservation.RoomID,
@@ -100,7 +131,18 @@ func (s *ReservationService) CheckInReservation(id uuid.UUID) error {
 	return s.roomRepo.UpdateRoomStatus(reservation.RoomID, models.RoomStatusOccupied)
 }
 
-func (s *ReservationService) CancelReservation(id uuid.UUID) error {
+// CancelReservation cancels an active reservation. Deposits still held on
+// it are settled in the same transaction: credited to the guest's account,
+// or with leftover REFUND recorded as refunded on the day of cancellation.
+func (s *ReservationService) CancelReservation(id uuid.UUID, leftover models.DepositLeftover) error {
+	switch leftover {
+	case "":
+		leftover = models.DepositLeftoverCredit
+	case models.DepositLeftoverCredit, models.DepositLeftoverRefund:
+	default:
+		return ErrInvalidDepositLeftover
+	}
+
 	reservation, err := s.repo.FindByID(id)
 	if err != nil {
 		return err
@@ -110,9 +152,13 @@ func (s *ReservationService) CancelReservation(id uuid.UUID) error {
 		return errors.New("only active reservations can be cancelled")
 	}
 
-	// Update reservation status to cancelled
-	reservation.Status = models.ReservationStatusCancelled
-	err = s.repo.Update(reservation)
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		reservations := s.repo.WithTx(tx)
+		if err := reservations.UpdateStatus(id, models.ReservationStatusCancelled); err != nil {
+			return err
+		}
+		return s.settleHeldDeposits(tx, reservation, leftover)
+	})
 	if err != nil {
 		return err
 	}
@@ -143,3 +189,88 @@ func (s *ReservationService) CheckoutReservation(id uuid.UUID, checkoutDate stri
 	// Update room status to available
 	return s.roomRepo.UpdateRoomStatus(reservation.RoomID, models.RoomStatusAvailable)
 }
+
+// AddDeposit records an advance paid against a reservation. The deposit is
+// held until the reservation's room bill is created, when it is applied to
+// the bill. Deposits can't be taken once the stay has been billed; payments
+// go against the bill instead.
+func (s *ReservationService) AddDeposit(deposit *models.ReservationDeposit) error {
+	if deposit.Amount <= 0 {
+		return ErrInvalidPaymentAmount
+	}
+
+	method, err := resolvePaymentMethod(s.methodRepo, deposit.PaymentMethod)
+	if err != nil {
+		return err
+	}
+	deposit.PaymentMethod = method.Name
+	deposit.ReferenceNumber = strings.TrimSpace(deposit.ReferenceNumber)
+	if method.RequiresReference && deposit.ReferenceNumber == "" {
+		return ErrReferenceRequired
+	}
+
+	if deposit.DepositDate == "" {
+		deposit.DepositDate = formatDate(time.Now())
+	} else if _, err := parseDate(deposit.DepositDate); err != nil {
+		return ErrInvalidDepositDate
+	}
+
+	reservation, err := s.repo.FindByID(deposit.ReservationID)
+	if err != nil {
+		return ErrReservationNotFound
+	}
+	if reservation.Status == models.ReservationStatusCancelled {
+		return ErrReservationNotBillable
+	}
+
+	if err := checkNotRoomBilled(s.billRepo, reservation.ID); err != nil {
+		return err
+	}
+
+	deposit.CustomerID = reservation.CustomerID
+	deposit.BillID = nil
+	deposit.AppliedAmount, deposit.RefundedAmount, deposit.CreditedAmount = 0, 0, 0
+	deposit.SettledDate = nil
+	return s.repo.CreateDeposit(deposit)
+}
+
+// settleHeldDeposits gives back the deposits held on a cancelled
+// reservation in full, as credit or as a refund
+func (s *ReservationService) settleHeldDeposits(tx *gorm.DB, reservation *models.Reservation, leftover models.DepositLeftover) error {
+	reservations := s.repo.WithTx(tx)
+	deposits, err := reservations.FindHeldDeposits(reservation.ID)
+	if err != nil {
+		return err
+	}
+
+	settled := formatDate(time.Now())
+	for i := range deposits {
+		deposit := &deposits[i]
+		if leftover == models.DepositLeftoverCredit {
+			credit := &models.CustomerCredit{
+				CustomerID:  deposit.CustomerID,
+				Amount:      deposit.Amount,
+				Description: "Advance on cancelled reservation",
+			}
+			if err := s.creditRepo.WithTx(tx).Create(credit); err != nil {
+				return err
+			}
+			deposit.CreditedAmount = deposit.Amount
+		} else {
+			deposit.RefundedAmount = deposit.Amount
+		}
+		deposit.SettledDate = &settled
+		if err := reservations.SettleDeposit(deposit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDeposits returns the deposits taken against a reservation
+func (s *ReservationService) GetDeposits(reservationID uuid.UUID) ([]models.ReservationDeposit, error) {
+	if _, err := s.repo.FindByID(reservationID); err != nil {
+		return nil, ErrReservationNotFound
+	}
+	return s.repo.FindDeposits(reservationID)
+}
@@ -1,10 +1,30 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"trinity-lodge/internal/escpos"
 	"trinity-lodge/internal/models"
 	"trinity-lodge/internal/repository"
+	"trinity-lodge/internal/upi"
+
+	"gorm.io/gorm"
 )
 
+// ErrInvoiceNumberLowered is returned when a next invoice number is set below
+// a number that has already been issued this financial year
+var ErrInvoiceNumberLowered = errors.New("next invoice number cannot be lower than one already issued this financial year")
+
+// ErrInvalidUPIVPA is returned when the UPI address isn't of the form
+// name@bank
+var ErrInvalidUPIVPA = errors.New("UPI VPA must look like name@bank")
+
+// ErrInvalidPrinterAddress is returned when a printer address isn't a host
+// name or IP address with an optional port
+var ErrInvalidPrinterAddress = errors.New("printer address must be a host name or IP address with an optional port")
+
 type SettingsService struct {
 	repo *repository.SettingsRepository
 }
@@ -13,6 +33,9 @@ func NewSettingsService(repo *repository.SettingsRepository) *SettingsService {
 	return &SettingsService{repo: repo}
 }
 
+// Get returns the lodge settings. The next numbers reported for each series
+// are those of the current financial year, including the number a series
+// will start at when it hasn't been used this year yet.
 func (s *SettingsService) Get() (*models.Settings, error) {
 	settings, err := s.repo.Get()
 	if err != nil {
@@ -26,9 +49,154 @@ func (s *SettingsService) Get() (*models.Settings, error) {
 			StateCode: "",
 		}, nil
 	}
+
+	fy := financialYear(time.Now())
+	for _, series := range models.AllInvoiceSeries {
+		_, _, next := settings.Series(series)
+		counter, err := s.repo.FindInvoiceCounter(series, fy)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			start, err := s.repo.FirstInvoiceNumber(settings, series)
+			if err != nil {
+				return nil, err
+			}
+			*next = start
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		*next = counter.NextNumber
+	}
 	return settings, nil
 }
 
+// Save stores the lodge settings. Number series that are left blank keep
+// their current prefix, format and counter, so saving the lodge details
+// never resets invoice numbering. A changed next number applies to the
+// current financial year. Printer addresses and the UPI payee are kept as
+// they are; they are changed through SavePrinters and SaveUPI.
 func (s *SettingsService) Save(settings *models.Settings) error {
-	return s.repo.Upsert(settings)
+	existing, err := s.Get()
+	if err != nil {
+		return err
+	}
+
+	if settings.RoundingMode == "" {
+		settings.RoundingMode = existing.RoundingMode
+	}
+	if settings.ReceiptPaperWidth == 0 {
+		settings.ReceiptPaperWidth = existing.ReceiptPaperWidth
+	}
+	settings.ReceiptPrinterAddress = existing.ReceiptPrinterAddress
+	settings.KitchenPrinterAddress = existing.KitchenPrinterAddress
+	settings.UPIVPA = existing.UPIVPA
+	settings.UPIPayeeName = existing.UPIPayeeName
+
+	// Check every series before writing anything, so a bad format on one
+	// series doesn't leave the others' counters changed
+	var changed []models.InvoiceSeries
+	for _, series := range models.AllInvoiceSeries {
+		prefix, format, next := seriesFields(settings, series)
+		existingPrefix, existingFormat, existingNext := existing.Series(series)
+
+		if *prefix == "" {
+			*prefix = existingPrefix
+		}
+		if *format == "" {
+			*format = existingFormat
+		}
+		if *format != "" {
+			if err := validateInvoiceFormat(*format); err != nil {
+				return fmt.Errorf("%s: %w", series, err)
+			}
+		}
+		if *next == 0 {
+			*next = *existingNext
+		}
+		if *next != *existingNext {
+			changed = append(changed, series)
+		}
+	}
+
+	fy := financialYear(time.Now())
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		for _, series := range changed {
+			_, _, next := seriesFields(settings, series)
+			counter, err := repo.FindInvoiceCounter(series, fy)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if counter != nil && *next < counter.NextNumber {
+				return fmt.Errorf("%s: %w", series, ErrInvoiceNumberLowered)
+			}
+			if err := repo.SetInvoiceCounter(series, fy, *next); err != nil {
+				return err
+			}
+		}
+		return repo.Upsert(settings)
+	})
+}
+
+// SavePrinters sets the network addresses of the receipt and kitchen
+// printers. The server connects to whatever they name, so only admins may
+// change them. An empty address leaves that printer unconfigured.
+func (s *SettingsService) SavePrinters(receiptAddress, kitchenAddress string) (*models.Settings, error) {
+	receiptAddress = strings.TrimSpace(receiptAddress)
+	kitchenAddress = strings.TrimSpace(kitchenAddress)
+	for _, address := range []string{receiptAddress, kitchenAddress} {
+		if address == "" {
+			continue
+		}
+		if _, err := escpos.PrinterAddress(address); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPrinterAddress, err)
+		}
+	}
+
+	settings, err := s.Get()
+	if err != nil {
+		return nil, err
+	}
+	settings.ReceiptPrinterAddress = receiptAddress
+	settings.KitchenPrinterAddress = kitchenAddress
+	if err := s.repo.Upsert(settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SaveUPI sets the UPI address that bill QR codes ask guests to pay and the
+// payee name shown with it. Payments go wherever the address points, so only
+// admins may change it. An empty address turns UPI QR codes off.
+func (s *SettingsService) SaveUPI(vpa, payeeName string) (*models.Settings, error) {
+	vpa = strings.TrimSpace(vpa)
+	if vpa != "" && !upi.ValidVPA(vpa) {
+		return nil, ErrInvalidUPIVPA
+	}
+
+	settings, err := s.Get()
+	if err != nil {
+		return nil, err
+	}
+	settings.UPIVPA = vpa
+	settings.UPIPayeeName = strings.TrimSpace(payeeName)
+	if err := s.repo.Upsert(settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// seriesFields returns pointers to the prefix, format and next-number
+// fields of a series so they can be filled in place
+func seriesFields(settings *models.Settings, series models.InvoiceSeries) (prefix, format *string, next *int) {
+	switch series {
+	case models.InvoiceSeriesNonGST:
+		return &settings.NonGSTInvoicePrefix, &settings.NonGSTInvoiceFormat, &settings.NonGSTInvoiceNextNumber
+	case models.InvoiceSeriesCreditNote:
+		return &settings.CreditNotePrefix, &settings.CreditNoteFormat, &settings.CreditNoteNextNumber
+	case models.InvoiceSeriesDebitNote:
+		return &settings.DebitNotePrefix, &settings.DebitNoteFormat, &settings.DebitNoteNextNumber
+	default:
+		return &settings.GSTInvoicePrefix, &settings.GSTInvoiceFormat, &settings.GSTInvoiceNextNumber
+	}
 }
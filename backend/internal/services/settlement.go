@@ -0,0 +1,39 @@
+package services
+
+import (
+	"errors"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// refreshSettlement recomputes the amount paid and balance due on a bill
+// from its payments and credit notes, and updates its status to match. Run
+// it with repositories bound to the transaction that wrote the payment or
+// note, so the figures never disagree with the rows behind them.
+func refreshSettlement(bills *repository.BillRepository, payments *repository.PaymentRepository, billID uuid.UUID) (*models.Bill, error) {
+	bill, err := bills.FindByID(billID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBillNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	paid, err := payments.TotalForBill(billID)
+	if err != nil {
+		return nil, err
+	}
+	credited, err := bills.CreditedTotal(billID)
+	if err != nil {
+		return nil, err
+	}
+
+	bill.ApplyPayments(paid, credited)
+	if err := bills.UpdateSettlement(bill); err != nil {
+		return nil, err
+	}
+	return bill, nil
+}
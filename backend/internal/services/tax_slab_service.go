@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"trinity-lodge/internal/models"
+	"trinity-lodge/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidTaxSlab = errors.New("invalid tax slab")
+
+type TaxSlabService struct {
+	repo *repository.TaxSlabRepository
+}
+
+func NewTaxSlabService(repo *repository.TaxSlabRepository) *TaxSlabService {
+	return &TaxSlabService{repo: repo}
+}
+
+func (s *TaxSlabService) CreateTaxSlab(slab *models.TaxSlab) error {
+	if err := validateTaxSlab(slab); err != nil {
+		return err
+	}
+	return s.repo.Create(slab)
+}
+
+func (s *TaxSlabService) GetAllTaxSlabs() ([]models.TaxSlab, error) {
+	return s.repo.FindAll()
+}
+
+func (s *TaxSlabService) UpdateTaxSlab(slab *models.TaxSlab) error {
+	existing, err := s.repo.FindByID(slab.ID)
+	if err != nil {
+		return err
+	}
+	if err := validateTaxSlab(slab); err != nil {
+		return err
+	}
+	slab.CreatedAt = existing.CreatedAt
+	return s.repo.Update(slab)
+}
+
+func (s *TaxSlabService) DeleteTaxSlab(id uuid.UUID) error {
+	return s.repo.Delete(id)
+}
+
+func validateTaxSlab(slab *models.TaxSlab) error {
+	if slab.ItemType == "" {
+		return fmt.Errorf("%w: item type is required", ErrInvalidTaxSlab)
+	}
+	if slab.MinAmount < 0 {
+		return fmt.Errorf("%w: minimum amount cannot be negative", ErrInvalidTaxSlab)
+	}
+	if slab.MaxAmount != nil && *slab.MaxAmount < slab.MinAmount {
+		return fmt.Errorf("%w: maximum amount is below the minimum", ErrInvalidTaxSlab)
+	}
+	if slab.Rate < 0 || slab.Rate > 100 {
+		return fmt.Errorf("%w: rate must be between 0 and 100", ErrInvalidTaxSlab)
+	}
+	if _, err := time.Parse("2006-01-02", slab.EffectiveFrom); err != nil {
+		return fmt.Errorf("%w: effective_from must be a YYYY-MM-DD date", ErrInvalidTaxSlab)
+	}
+	if slab.EffectiveTo != nil {
+		if _, err := time.Parse("2006-01-02", *slab.EffectiveTo); err != nil {
+			return fmt.Errorf("%w: effective_to must be a YYYY-MM-DD date", ErrInvalidTaxSlab)
+		}
+		if *slab.EffectiveTo < slab.EffectiveFrom {
+			return fmt.Errorf("%w: effective_to is before effective_from", ErrInvalidTaxSlab)
+		}
+	}
+	return nil
+}
+
+// slabRate picks the rate for a per-unit amount from the slabs in force.
+// When slabs from several effective dates overlap, the most recent one wins.
+func slabRate(slabs []models.TaxSlab, amount models.Money) (float64, bool) {
+	for _, slab := range slabs {
+		if slab.Covers(amount) {
+			return slab.Rate, true
+		}
+	}
+	return 0, false
+}
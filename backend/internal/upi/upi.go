@@ -0,0 +1,88 @@
+// Package upi builds UPI payment deep links and the QR codes that carry them.
+package upi
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"trinity-lodge/internal/models"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+var vpaPattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{2,256}@[a-zA-Z][a-zA-Z0-9]{1,63}$`)
+
+// ValidVPA reports whether vpa looks like a UPI virtual payment address,
+// e.g. trinitylodge@okaxis
+func ValidVPA(vpa string) bool {
+	return vpaPattern.MatchString(vpa)
+}
+
+// Payment describes a request for a fixed amount to a payee's VPA
+type Payment struct {
+	VPA       string // payee address, e.g. trinitylodge@okaxis
+	PayeeName string
+	Amount    models.Money
+	Reference string // transaction reference, e.g. the invoice number
+	Note      string // shown to the payer in their UPI app
+}
+
+var linkEscaper = strings.NewReplacer("+", "%20", "%40", "@")
+
+// Link returns the upi://pay deep link for the payment. Scanning it opens the
+// payer's UPI app with the payee and amount filled in.
+func (p Payment) Link() string {
+	params := []struct{ key, value string }{
+		{"pa", p.VPA},
+		{"pn", p.PayeeName},
+		{"am", p.Amount.String()},
+		{"cu", "INR"},
+		{"tr", p.Reference},
+		{"tn", p.Note},
+	}
+
+	var query []string
+	for _, param := range params {
+		if param.value == "" {
+			continue
+		}
+		// UPI apps expect %20 rather than + for spaces, and an unescaped @
+		// in the VPA
+		value := linkEscaper.Replace(url.QueryEscape(param.value))
+		query = append(query, param.key+"="+value)
+	}
+	return "upi://pay?" + strings.Join(query, "&")
+}
+
+// PNG renders the payment link as a square QR code of size pixels
+func (p Payment) PNG(size int) ([]byte, error) {
+	return qrcode.Encode(p.Link(), qrcode.Medium, size)
+}
+
+// SVG renders the payment link as a QR code of size pixels, drawn as a
+// single path so it scales cleanly when printed
+func (p Payment) SVG(size int) ([]byte, error) {
+	qr, err := qrcode.New(p.Link(), qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := qr.Bitmap()
+	modules := len(bitmap)
+
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	svg := fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+			`<rect width="100%%" height="100%%" fill="#fff"/><path fill="#000" d="%s"/></svg>`,
+		size, size, modules, modules, path.String(),
+	)
+	return []byte(svg), nil
+}
@@ -35,7 +35,7 @@ export default function BillEditor({ billType, reservationId, reservation, onSav
   const [ratePerNight, setRatePerNight] = useState(reservation?.room?.type?.default_rate || 1000)
 
   const [lineItems, setLineItems] = useState<Omit<BillLineItem, 'id' | 'bill_id' | 'created_at'>[]>([
-    { description: '', amount: 0 },
+    { item_type: 'OTHER', description: '', amount: 0 },
   ])
   const [enableGST, setEnableGST] = useState(true)
   const [gstPercentage, setGstPercentage] = useState(18)
@@ -54,25 +54,28 @@ export default function BillEditor({ billType, reservationId, reservation, onSav
   const numberOfDays = calculateDays()
 
   const subtotal = lineItems.reduce((sum, item) => sum + (item.amount || 0), 0)
-  const taxAmount = enableGST ? (subtotal * gstPercentage) / 100 : 0
+  const taxAmount = enableGST ? ((subtotal - discountAmount) * gstPercentage) / 100 : 0
   const totalAmount = subtotal + taxAmount - discountAmount
 
   useEffect(() => {
     // If this is a room bill with reservation, pre-populate with room charges
     if ((billType === 'ROOM' || billType === 'MANUAL') && reservation) {
       const days = calculateDays()
-      const roomCharge = (reservation.room?.type?.default_rate || ratePerNight) * days
+      const nightlyRate = reservation.room?.type?.default_rate || ratePerNight
       setLineItems([
         {
-          description: `Room Charge - ${reservation.room?.room_number || ''} (${days} ${days === 1 ? 'day' : 'days'} × ₹${reservation.room?.type?.default_rate || ratePerNight}/night)`,
-          amount: roomCharge
+          item_type: 'ROOM',
+          description: `Room Charge - ${reservation.room?.room_number || ''} (${days} ${days === 1 ? 'day' : 'days'} × ₹${nightlyRate}/night)`,
+          quantity: days,
+          unit_price: nightlyRate,
+          amount: nightlyRate * days
         }
       ])
     }
   }, [billType, reservation, checkInDate, checkOutDate])
 
   const addLineItem = () => {
-    setLineItems([...lineItems, { description: '', amount: 0 }])
+    setLineItems([...lineItems, { item_type: 'OTHER', description: '', amount: 0 }])
   }
 
   const removeLineItem = (index: number) => {
@@ -84,11 +87,15 @@ export default function BillEditor({ billType, reservationId, reservation, onSav
   const updateLineItem = (index: number, field: 'description' | 'amount', value: string | number) => {
     const updated = [...lineItems]
     updated[index] = { ...updated[index], [field]: value }
+    // A hand-edited amount replaces the quantity × rate breakdown
+    if (field === 'amount') {
+      updated[index] = { ...updated[index], quantity: undefined, unit_price: undefined }
+    }
     setLineItems(updated)
   }
 
   const addCommonItem = (description: string, amount: number) => {
-    setLineItems([...lineItems, { description, amount }])
+    setLineItems([...lineItems, { item_type: 'OTHER', description, amount }])
   }
 
   const calculateRoomCharge = () => {
@@ -99,9 +106,9 @@ export default function BillEditor({ billType, reservationId, reservation, onSav
 
     // Replace first item or add if empty
     if (lineItems.length === 1 && lineItems[0].description === '' && lineItems[0].amount === 0) {
-      setLineItems([{ description, amount: roomCharge }])
+      setLineItems([{ item_type: 'ROOM', description, quantity: days, unit_price: ratePerNight, amount: roomCharge }])
     } else {
-      setLineItems([{ description, amount: roomCharge }, ...lineItems])
+      setLineItems([{ item_type: 'ROOM', description, quantity: days, unit_price: ratePerNight, amount: roomCharge }, ...lineItems])
     }
   }
 
@@ -64,6 +64,8 @@ export default function BillViewModal({ open, onOpenChange, bill }: BillViewModa
       FINALIZED: 'bg-blue-50 text-blue-600 border-blue-200',
       PAID: 'bg-green-50 text-green-600 border-green-200',
       UNPAID: 'bg-red-50 text-red-600 border-red-200',
+      PARTIALLY_PAID: 'bg-amber-50 text-amber-600 border-amber-200',
+      CANCELLED: 'bg-red-50 text-red-600 border-red-200',
     }
     return styles[status] || styles.DRAFT
   }
@@ -11,6 +11,8 @@ const getStatusBadge = (status: Bill['status']) => {
     FINALIZED: 'bg-blue-50 text-blue-600 border-blue-200',
     PAID: 'bg-green-50 text-green-600 border-green-200',
     UNPAID: 'bg-red-50 text-red-600 border-red-200',
+    PARTIALLY_PAID: 'bg-amber-50 text-amber-600 border-amber-200',
+    CANCELLED: 'bg-red-50 text-red-600 border-red-200',
   }
 
   const dots = {
@@ -18,6 +20,8 @@ const getStatusBadge = (status: Bill['status']) => {
     FINALIZED: 'bg-blue-500',
     PAID: 'bg-green-500',
     UNPAID: 'bg-red-500',
+    PARTIALLY_PAID: 'bg-amber-500',
+    CANCELLED: 'bg-red-500',
   }
 
   return (
@@ -137,7 +141,7 @@ export default function BillList() {
     unpaid: bills.filter(b => b.status === 'UNPAID').length,
     draft: bills.filter(b => b.status === 'DRAFT').length,
     totalRevenue: bills.filter(b => b.status === 'PAID').reduce((sum, b) => sum + b.total_amount, 0),
-    pendingRevenue: bills.filter(b => b.status === 'UNPAID' || b.status === 'FINALIZED').reduce((sum, b) => sum + b.total_amount, 0),
+    pendingRevenue: bills.filter(b => b.status === 'UNPAID' || b.status === 'PARTIALLY_PAID').reduce((sum, b) => sum + b.balance_due, 0),
   }
 
   const statCards = [
@@ -231,8 +235,10 @@ export default function BillList() {
               <option value="ALL">All Status</option>
               <option value="PAID">Paid</option>
               <option value="UNPAID">Unpaid</option>
+              <option value="PARTIALLY_PAID">Partially Paid</option>
               <option value="FINALIZED">Finalized</option>
               <option value="DRAFT">Draft</option>
+              <option value="CANCELLED">Cancelled</option>
             </select>
           </div>
 
@@ -91,13 +91,14 @@ export default function CustomerDetails() {
         bill_type: billData.billType as 'ROOM' | 'WALK_IN' | 'FOOD' | 'MANUAL',
         bill_date: new Date().toISOString().split('T')[0],
         is_gst_bill: billData.enableGST,
-        subtotal: billData.subtotal,
-        tax_amount: billData.taxAmount,
+        tax_rate: billData.enableGST ? billData.gstPercentage : 0,
         discount_amount: billData.discountAmount,
-        total_amount: billData.totalAmount,
         status: 'FINALIZED' as const,
         line_items: billData.lineItems.map(item => ({
+          item_type: item.item_type,
           description: item.description,
+          quantity: item.quantity,
+          unit_price: item.unit_price,
           amount: item.amount,
         })),
       }
@@ -169,6 +170,7 @@ export default function CustomerDetails() {
       FINALIZED: 'bg-blue-50 text-blue-600 border-blue-200',
       PAID: 'bg-green-50 text-green-600 border-green-200',
       UNPAID: 'bg-red-50 text-red-600 border-red-200',
+      PARTIALLY_PAID: 'bg-amber-50 text-amber-600 border-amber-200',
     }
 
     return (
@@ -53,7 +53,7 @@ export default function Dashboard() {
     totalCustomers: customers.length,
     activeReservations: reservations.filter(r => r.status === 'ACTIVE').length,
     availableRooms: rooms.filter(r => r.status === 'AVAILABLE').length,
-    pendingBills: bills.filter(b => b.status === 'DRAFT' || b.status === 'UNPAID' || b.status === 'FINALIZED').length,
+    pendingBills: bills.filter(b => b.status === 'DRAFT' || b.status === 'UNPAID' || b.status === 'PARTIALLY_PAID').length,
   }
 
   const recentReservations = reservations
@@ -71,11 +71,13 @@ export default function Dashboard() {
       FINALIZED: 'bg-blue-50 text-blue-600 border-blue-200',
       PAID: 'bg-green-50 text-green-600 border-green-200',
       UNPAID: 'bg-red-50 text-red-600 border-red-200',
+      PARTIALLY_PAID: 'bg-amber-50 text-amber-600 border-amber-200',
+      CANCELLED: 'bg-red-50 text-red-600 border-red-200',
     }
 
     return (
       <span className={`inline-flex items-center gap-1.5 px-2.5 py-1 rounded-full text-xs font-medium border ${styles[status]}`}>
-        <span className={`w-1.5 h-1.5 rounded-full ${status === 'PAID' ? 'bg-green-500' : status === 'UNPAID' ? 'bg-red-500' : status === 'FINALIZED' ? 'bg-blue-500' : 'bg-gray-400'}`} />
+        <span className={`w-1.5 h-1.5 rounded-full ${status === 'PAID' ? 'bg-green-500' : status === 'UNPAID' || status === 'CANCELLED' ? 'bg-red-500' : status === 'FINALIZED' ? 'bg-blue-500' : 'bg-gray-400'}`} />
         {status}
       </span>
     )
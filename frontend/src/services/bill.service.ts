@@ -6,21 +6,41 @@ export interface CreateBillRequest {
   reservation_id?: string;
   bill_type: 'ROOM' | 'WALK_IN' | 'FOOD' | 'MANUAL';
   bill_date: string;
-  subtotal: number;
-  tax_amount: number;
+  is_gst_bill?: boolean;
+  tax_rate?: number;
+  place_of_supply?: string;
   discount_amount: number;
-  total_amount: number;
   status?: 'DRAFT' | 'FINALIZED' | 'PAID' | 'UNPAID';
   line_items: {
+    item_type?: 'ROOM' | 'OTHER';
     description: string;
+    hsn_sac_code?: string;
+    quantity?: number;
+    unit_price?: number;
+    discount_amount?: number;
+    tax_rate?: number;
     amount: number;
   }[];
 }
 
 export interface CreatePaymentRequest {
   amount: number;
-  payment_method: 'CASH' | 'CARD' | 'UPI';
+  payment_method: string;
   payment_date: string;
+  reference_number?: string;
+  note?: string;
+  allow_overpayment?: boolean;
+}
+
+export interface SplitPaymentRequest {
+  payment_date: string;
+  legs: {
+    amount: number;
+    payment_method: string;
+    reference_number?: string;
+    note?: string;
+  }[];
+  allow_overpayment?: boolean;
 }
 
 export const billService = {
@@ -44,12 +64,21 @@ export const billService = {
     return response.data;
   },
 
+  async cancel(id: string, reason: string): Promise<void> {
+    await apiClient.post(`/api/bills/${id}/cancel`, { reason });
+  },
+
   // Payments
   async createPayment(billId: string, data: CreatePaymentRequest): Promise<Payment> {
     const response = await apiClient.post<Payment>(`/api/bills/${billId}/payments`, data);
     return response.data;
   },
 
+  async createSplitPayment(billId: string, data: SplitPaymentRequest): Promise<Payment[]> {
+    const response = await apiClient.post<Payment[]>(`/api/bills/${billId}/payments/split`, data);
+    return response.data;
+  },
+
   async getPaymentsByBillId(billId: string): Promise<Payment[]> {
     const response = await apiClient.get<Payment[]>(`/api/bills/${billId}/payments`);
     return response.data;
@@ -6,6 +6,8 @@ export interface CreateReservationRequest {
   room_id: string;
   check_in_date: string;
   expected_check_out_date?: string;
+  occupancy?: number;
+  rate_plan_code?: string;
 }
 
 export interface CheckoutRequest {
@@ -11,4 +11,14 @@ export const settingsService = {
     const response = await apiClient.post<Settings>('/api/settings', settings)
     return response.data
   },
+
+  async savePrinters(printers: Pick<Settings, 'receipt_printer_address' | 'kitchen_printer_address'>): Promise<Settings> {
+    const response = await apiClient.post<Settings>('/api/settings/printers', printers)
+    return response.data
+  },
+
+  async saveUPI(upi: Pick<Settings, 'upi_vpa' | 'upi_payee_name'>): Promise<Settings> {
+    const response = await apiClient.post<Settings>('/api/settings/upi', upi)
+    return response.data
+  },
 }
@@ -13,6 +13,9 @@ export interface Customer {
   full_name: string
   phone: string
   address: string
+  state_name?: string
+  state_code?: string
+  gst_number?: string
   id_proof_type: string
   id_proof_number: string
   created_at: string
@@ -28,6 +31,61 @@ export interface RoomType {
   updated_at: string
 }
 
+export interface RatePlan {
+  id: string
+  room_type_id: string
+  room_type?: RoomType
+  name: string
+  code?: string
+  valid_from?: string
+  valid_to?: string
+  days_of_week: string
+  priority: number
+  prices: RatePlanPrice[]
+  created_at: string
+  updated_at: string
+}
+
+export interface RatePlanPrice {
+  id: string
+  rate_plan_id: string
+  occupancy: number
+  rate: number
+}
+
+export interface NightRate {
+  date: string
+  rate: number
+  rate_plan_id?: string
+  rate_plan_name: string
+}
+
+export interface Quote {
+  room_type_id: string
+  room_type_name: string
+  check_in: string
+  check_out: string
+  occupancy: number
+  rate_plan_code?: string
+  nights: NightRate[]
+  total: number
+}
+
+export interface RoomTypeAvailability {
+  room_type: RoomType
+  available: number
+  rooms: Room[]
+  quote: Quote
+}
+
+export interface Availability {
+  check_in: string
+  check_out: string
+  occupancy: number
+  rate_plan_code?: string
+  room_types: RoomTypeAvailability[]
+}
+
 export interface Room {
   id: string
   room_number: string
@@ -47,19 +105,121 @@ export interface Reservation {
   actual_check_in_date?: string
   expected_check_out_date: string
   actual_check_out_date?: string
+  occupancy: number
+  rate_plan_code?: string
   status: 'ACTIVE' | 'COMPLETED' | 'CANCELLED'
   created_at: string
   updated_at: string
   customer?: Customer
   room?: Room
+  deposits?: ReservationDeposit[]
+}
+
+export interface ReservationDeposit {
+  id: string
+  reservation_id: string
+  customer_id: string
+  amount: number
+  payment_method: string
+  reference_number?: string
+  note?: string
+  deposit_date: string
+  received_by: string
+  bill_id?: string
+  applied_amount: number
+  refunded_amount: number
+  credited_amount: number
+  settled_date?: string
+  created_at: string
+}
+
+export interface Folio {
+  id: string
+  reservation_id: string
+  customer_id: string
+  status: 'OPEN' | 'CLOSED'
+  closed_at?: string
+  created_at: string
+  updated_at: string
+  charges: FolioCharge[]
+  unbilled_total: number
+}
+
+export interface FolioCharge {
+  id: string
+  folio_id: string
+  bill_type: 'FOOD' | 'MANUAL'
+  charge_date: string
+  description: string
+  hsn_sac_code?: string
+  quantity: number
+  unit_price: number
+  tax_rate: number
+  amount: number
+  catalog_item_id?: string
+  bill_id?: string
+  posted_by: string
+  created_at: string
+}
+
+export interface CatalogItem {
+  id: string
+  name: string
+  category: 'FOOD' | 'BEVERAGE' | 'LAUNDRY' | 'MINIBAR' | 'SERVICE' | 'OTHER'
+  default_price: number
+  hsn_sac_code?: string
+  tax_rate: number
+  is_active: boolean
+  created_at: string
+  updated_at: string
+}
+
+export interface KitchenOrder {
+  id: string
+  order_number: number
+  table_number?: string
+  room_id?: string
+  room?: Room
+  reservation_id?: string
+  bill_id?: string
+  status: 'OPEN' | 'CLOSED' | 'CANCELLED'
+  kot_count: number
+  created_by: string
+  closed_at?: string
+  created_at: string
+  updated_at: string
+  items: KitchenOrderItem[]
+}
+
+export interface KitchenOrderItem {
+  id: string
+  order_id: string
+  catalog_item_id: string
+  description: string
+  hsn_sac_code: string
+  quantity: number
+  unit_price: number
+  tax_rate: number
+  note?: string
+  kot_number: number
+  created_at: string
 }
 
 // Bill types
 export interface BillLineItem {
   id: string
   bill_id: string
+  item_type?: 'ROOM' | 'OTHER'
   description: string
+  hsn_sac_code?: string
+  quantity?: number
+  unit_price?: number
+  discount_amount?: number
   amount: number
+  tax_rate?: number
+  tax_amount?: number
+  catalog_item_id?: string
+  original_line_item_id?: string
   created_at: string
 }
 
@@ -68,20 +228,36 @@ export interface Bill {
   customer_id: string
   reservation_id?: string
   bill_type: 'ROOM' | 'WALK_IN' | 'FOOD' | 'MANUAL'
+  document_type?: 'INVOICE' | 'CREDIT_NOTE' | 'DEBIT_NOTE'
+  original_bill_id?: string
+  note_reason?: string
   bill_date: string
   invoice_number: string
   is_gst_bill: boolean
+  place_of_supply?: string
+  is_inter_state?: boolean
   subtotal: number
   tax_amount: number
+  cgst_amount?: number
+  sgst_amount?: number
+  igst_amount?: number
   discount_amount: number
+  round_off?: number
   total_amount: number
-  status: 'DRAFT' | 'FINALIZED' | 'PAID' | 'UNPAID'
+  amount_in_words?: string
+  amount_paid: number
+  balance_due: number
+  status: 'DRAFT' | 'FINALIZED' | 'UNPAID' | 'PARTIALLY_PAID' | 'PAID' | 'CANCELLED'
   generated_by: string
+  cancelled_at?: string
+  cancelled_by?: string
+  cancel_reason?: string
   created_at: string
   updated_at: string
   customer?: Customer
   reservation?: Reservation
   line_items?: BillLineItem[]
+  notes?: Bill[]
 }
 
 // Payment types
@@ -89,8 +265,66 @@ export interface Payment {
   id: string
   bill_id: string
   amount: number
-  payment_method: 'Cash' | 'Card' | 'UPI'
+  excess_credited?: number
+  payment_method: string
+  payment_date: string
+  reference_number?: string
+  note?: string
+  deposit_id?: string
+  customer_payment_id?: string
+  refunds?: PaymentRefund[]
+}
+
+export interface CustomerPayment {
+  id: string
+  customer_id: string
+  amount: number
+  excess_credited: number
+  payment_method: string
   payment_date: string
+  reference_number?: string
+  note?: string
+  received_by: string
+  created_at: string
+  customer?: Customer
+  allocations?: (Payment & { bill?: Bill })[]
+}
+
+export interface PaymentRefund {
+  id: string
+  payment_id: string
+  bill_id: string
+  type: 'REFUND' | 'REVERSAL'
+  amount: number
+  payment_method: string
+  refund_date: string
+  reason: string
+  created_by: string
+  created_at: string
+}
+
+export interface PaymentMethodConfig {
+  id: string
+  name: string
+  requires_reference: boolean
+  is_active: boolean
+  sort_order: number
+}
+
+export interface CustomerCredit {
+  id: string
+  customer_id: string
+  amount: number
+  bill_id?: string
+  payment_id?: string
+  description: string
+  created_at: string
+}
+
+export interface CustomerCreditAccount {
+  customer_id: string
+  balance: number
+  entries: CustomerCredit[]
 }
 
 // Settings types
@@ -103,9 +337,23 @@ export interface Settings {
   state_name: string
   state_code: string
   gst_invoice_prefix: string
+  gst_invoice_format?: string
   gst_invoice_next_number: number
   non_gst_invoice_prefix: string
+  non_gst_invoice_format?: string
   non_gst_invoice_next_number: number
+  credit_note_prefix?: string
+  credit_note_format?: string
+  credit_note_next_number?: number
+  debit_note_prefix?: string
+  debit_note_format?: string
+  debit_note_next_number?: number
+  rounding_mode?: 'NEAREST' | 'UP' | 'DOWN' | 'NONE'
+  receipt_printer_address?: string
+  kitchen_printer_address?: string
+  receipt_paper_width?: 58 | 80
+  upi_vpa?: string
+  upi_payee_name?: string
   created_at?: string
   updated_at?: string
 }